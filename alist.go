@@ -28,6 +28,8 @@ func Init() bool {
 	bootstrap.InitSettings()
 	bootstrap.InitAccounts()
 	bootstrap.InitCache()
+	bootstrap.InitIndex()
+	bootstrap.InitTasks()
 	return true
 }
 
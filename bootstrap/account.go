@@ -13,7 +13,19 @@ func InitAccounts() {
 	if err := conf.DB.Find(&accounts).Error; err != nil {
 		log.Fatalf("failed sync init accounts")
 	}
-	for i, account := range accounts {
+	for i := range accounts {
+		// 旧版本没有挂载路径，只有一个账号时挂载在根目录，保持原有的访问路径
+		if accounts[i].MountPath == "" {
+			if len(accounts) == 1 {
+				accounts[i].MountPath = "/"
+			} else {
+				accounts[i].MountPath = "/" + accounts[i].Name
+			}
+			if err := conf.DB.Model(&accounts[i]).Update("mount_path", accounts[i].MountPath).Error; err != nil {
+				log.Errorf("failed set mount path of account [%s]: %s", accounts[i].Name, err.Error())
+			}
+		}
+		account := accounts[i]
 		model.RegisterAccount(account)
 		driver, ok := base.GetDriver(account.Type)
 		if !ok {
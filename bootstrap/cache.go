@@ -1,18 +1,199 @@
 package bootstrap
 
 import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"github.com/Xhofe/alist/conf"
+	"github.com/bradfitz/gomemcache/memcache"
 	"github.com/eko/gocache/v2/cache"
 	"github.com/eko/gocache/v2/store"
+	"github.com/go-redis/redis/v8"
 	goCache "github.com/patrickmn/go-cache"
 	log "github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
 // InitCache init cache
 func InitCache() {
 	log.Infof("init cache...")
-	goCacheClient := goCache.New(60*time.Minute, 120*time.Minute)
-	goCacheStore := store.NewGoCache(goCacheClient, nil)
-	conf.Cache = cache.New(goCacheStore)
+	if conf.Conf.Cache.Expiration <= 0 {
+		conf.Conf.Cache.Expiration = 60
+	}
+	config := conf.Conf.Cache
+	expiration := time.Duration(config.Expiration) * time.Minute
+	var cacheStore store.StoreInterface
+	switch config.Type {
+	case "", "memory":
+		goCacheClient := goCache.New(expiration, 2*expiration)
+		cacheStore = store.NewGoCache(goCacheClient, nil)
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:     config.Address,
+			Password: config.Password,
+			DB:       config.DB,
+		})
+		if err := client.Ping(conf.Ctx).Err(); err != nil {
+			log.Fatalf("failed to connect redis: %s", err.Error())
+		}
+		cacheStore = store.NewRedis(redisClient{client}, &store.Options{Expiration: expiration})
+	case "memcached":
+		memcacheClient := memcache.New(strings.Split(config.Address, ",")...)
+		cacheStore = newMemcacheStore(memcacheClient, &store.Options{Expiration: expiration})
+	default:
+		log.Fatalf("not supported cache type: %s", config.Type)
+	}
+	log.Infof("cache type: %s", cacheStore.GetType())
+	conf.Cache = cache.New(cacheStore)
+}
+
+// MemcacheVersionKey 保存缓存的版本，版本是所有key的一部分，清空缓存时更换版本，旧的key不再被访问，由memcached自行淘汰
+const MemcacheVersionKey = "alist:version"
+
+// memcacheStore memcached的key不能超过250字节且不能包含空格和控制字符，所以使用版本、key和tag的md5。
+// memcached没有办法只删除部分key，所以清空缓存时只更换版本，不使用FlushAll
+type memcacheStore struct {
+	*store.MemcacheStore
+	client  *memcache.Client
+	version atomic.Value // string
+}
+
+func newMemcacheStore(client *memcache.Client, options *store.Options) *memcacheStore {
+	s := &memcacheStore{MemcacheStore: store.NewMemcache(client, options), client: client}
+	if item, err := client.Get(MemcacheVersionKey); err == nil {
+		s.version.Store(string(item.Value))
+	} else if err = s.newVersion(); err != nil {
+		log.Fatalf("failed to connect memcached: %s", err.Error())
+	}
+	return s
+}
+
+func (s *memcacheStore) newVersion() error {
+	version := strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := s.client.Set(&memcache.Item{Key: MemcacheVersionKey, Value: []byte(version)}); err != nil {
+		return err
+	}
+	s.version.Store(version)
+	return nil
+}
+
+func (s *memcacheStore) key(key interface{}) string {
+	sum := md5.Sum([]byte(s.version.Load().(string) + "\n" + key.(string)))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *memcacheStore) tags(tags []string) []string {
+	res := make([]string, len(tags))
+	for i, tag := range tags {
+		res[i] = s.key(tag)
+	}
+	return res
+}
+
+func (s *memcacheStore) Get(ctx context.Context, key interface{}) (interface{}, error) {
+	return s.MemcacheStore.Get(ctx, s.key(key))
+}
+
+func (s *memcacheStore) GetWithTTL(ctx context.Context, key interface{}) (interface{}, time.Duration, error) {
+	return s.MemcacheStore.GetWithTTL(ctx, s.key(key))
+}
+
+func (s *memcacheStore) Set(ctx context.Context, key interface{}, value interface{}, options *store.Options) error {
+	if options != nil {
+		options = &store.Options{
+			Cost:       options.Cost,
+			Expiration: options.Expiration,
+			Tags:       s.tags(options.Tags),
+		}
+	}
+	return s.MemcacheStore.Set(ctx, s.key(key), value, options)
+}
+
+func (s *memcacheStore) Delete(ctx context.Context, key interface{}) error {
+	return s.MemcacheStore.Delete(ctx, s.key(key))
+}
+
+func (s *memcacheStore) Invalidate(ctx context.Context, options store.InvalidateOptions) error {
+	return s.MemcacheStore.Invalidate(ctx, store.InvalidateOptions{Tags: s.tags(options.Tags)})
+}
+
+// Clear change the version instead of flushing the whole memcached server
+func (s *memcacheStore) Clear(_ context.Context) error {
+	return s.newVersion()
+}
+
+// RedisKeyPrefix 所有的key(包括tag)都加上这个前缀，清空缓存时只删除这些key，不影响redis中的其他数据
+const RedisKeyPrefix = "alist:"
+
+// redisClient 给key加上前缀，FlushAll只删除带前缀的key
+type redisClient struct {
+	*redis.Client
+}
+
+func (c redisClient) Get(ctx context.Context, key string) *redis.StringCmd {
+	return c.Client.Get(ctx, RedisKeyPrefix+key)
+}
+
+func (c redisClient) TTL(ctx context.Context, key string) *redis.DurationCmd {
+	return c.Client.TTL(ctx, RedisKeyPrefix+key)
+}
+
+func (c redisClient) Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd {
+	return c.Client.Expire(ctx, RedisKeyPrefix+key, expiration)
+}
+
+func (c redisClient) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	return c.Client.Set(ctx, RedisKeyPrefix+key, value, expiration)
+}
+
+func (c redisClient) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = RedisKeyPrefix + key
+	}
+	return c.Client.Del(ctx, prefixed...)
+}
+
+// SAdd the members are the keys without prefix, they are prefixed again when deleted by Del
+func (c redisClient) SAdd(ctx context.Context, key string, members ...interface{}) *redis.IntCmd {
+	return c.Client.SAdd(ctx, RedisKeyPrefix+key, members...)
+}
+
+func (c redisClient) SMembers(ctx context.Context, key string) *redis.StringSliceCmd {
+	return c.Client.SMembers(ctx, RedisKeyPrefix+key)
+}
+
+// FlushAll delete the keys with the prefix instead of flushing all the databases
+func (c redisClient) FlushAll(ctx context.Context) *redis.StatusCmd {
+	cmd := redis.NewStatusCmd(ctx, "flushall")
+	iter := c.Client.Scan(ctx, 0, RedisKeyPrefix+"*", 1000).Iterator()
+	keys := make([]string, 0, 1000)
+	del := func() error {
+		if len(keys) == 0 {
+			return nil
+		}
+		err := c.Client.Del(ctx, keys...).Err()
+		keys = keys[:0]
+		return err
+	}
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == cap(keys) {
+			if err := del(); err != nil {
+				cmd.SetErr(err)
+				return cmd
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	if err := del(); err != nil {
+		cmd.SetErr(err)
+	}
+	return cmd
 }
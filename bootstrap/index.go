@@ -0,0 +1,21 @@
+package bootstrap
+
+import (
+	"github.com/Xhofe/alist/conf"
+	"github.com/Xhofe/alist/drivers/base"
+	"github.com/Xhofe/alist/model"
+	log "github.com/sirupsen/logrus"
+)
+
+// InitIndex schedule building the search index
+func InitIndex() {
+	spec, err := model.GetSettingByKey("search index cron")
+	if err != nil || spec.Value == "" {
+		return
+	}
+	log.Infof("init search index...")
+	_, err = conf.Cron.AddFunc(spec.Value, base.IndexAccounts)
+	if err != nil {
+		log.Errorf("failed to schedule search index: %s", err.Error())
+	}
+}
@@ -72,7 +72,7 @@ func InitModel() {
 		log.Fatalf("not supported database type: %s", databaseConfig.Type)
 	}
 	log.Infof("auto migrate model...")
-	err := conf.DB.AutoMigrate(&model.SettingItem{}, &model.Account{}, &model.Meta{})
+	err := conf.DB.AutoMigrate(&model.SettingItem{}, &model.Account{}, &model.Meta{}, &model.SearchFile{}, &model.User{}, &model.Share{}, &model.ShareLog{}, &model.Task{})
 	if err != nil {
 		log.Fatalf("failed to auto migrate")
 	}
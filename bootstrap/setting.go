@@ -162,6 +162,41 @@ func InitSettings() {
 			Description: "check down link password, your link will be 'https://alist.com/d/filename?pw=xxx'",
 			Group:       model.PUBLIC,
 		},
+		{
+			Key:         "sign expire",
+			Value:       "4",
+			Type:        "number",
+			Description: "expiration hours of the download sign, 0 means never expire",
+			Group:       model.PRIVATE,
+		},
+		{
+			Key:         "legacy sign",
+			Value:       "false",
+			Type:        "bool",
+			Description: "still accept the old sign only bound to file name, on when upgraded from old versions, turn off after all proxies are updated",
+			Group:       model.PRIVATE,
+		},
+		{
+			Key:         "search index cron",
+			Value:       "@every 6h",
+			Type:        "string",
+			Description: "cron spec to update search index of accounts allowed search, empty to disable, need restart",
+			Group:       model.PRIVATE,
+		},
+		{
+			Key:         "task workers",
+			Value:       "3",
+			Type:        "number",
+			Description: "number of background tasks running at the same time, need restart",
+			Group:       model.PRIVATE,
+		},
+		{
+			Key:         "task retries",
+			Value:       "2",
+			Type:        "number",
+			Description: "max retry times of a failed background task, need restart",
+			Group:       model.PRIVATE,
+		},
 		{
 			Key:         "WebDAV username",
 			Value:       "alist",
@@ -183,6 +218,10 @@ func InitSettings() {
 		o, err := model.GetSettingByKey(v.Key)
 		if err != nil {
 			if err == gorm.ErrRecordNotFound {
+				// 从旧版本升级时已有的链接和中转程序还在使用旧的签名
+				if v.Key == "legacy sign" && hasAccounts() {
+					v.Value = "true"
+				}
 				err = model.SaveSetting(v)
 				if err != nil {
 					log.Fatalf("failed write setting: %s", err.Error())
@@ -200,3 +239,10 @@ func InitSettings() {
 	}
 	model.LoadSettings()
 }
+
+// hasAccounts check whether there are accounts in the database, that is upgraded from an old version
+func hasAccounts() bool {
+	var count int64
+	conf.DB.Model(&model.Account{}).Count(&count)
+	return count > 0
+}
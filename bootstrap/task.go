@@ -0,0 +1,25 @@
+package bootstrap
+
+import (
+	"github.com/Xhofe/alist/drivers/base"
+	"github.com/Xhofe/alist/model"
+	log "github.com/sirupsen/logrus"
+	"strconv"
+)
+
+// InitTasks start the background task workers
+func InitTasks() {
+	log.Infof("init tasks...")
+	workers, retries := 3, 2
+	if setting, err := model.GetSettingByKey("task workers"); err == nil {
+		if n, err := strconv.Atoi(setting.Value); err == nil && n > 0 {
+			workers = n
+		}
+	}
+	if setting, err := model.GetSettingByKey("task retries"); err == nil {
+		if n, err := strconv.Atoi(setting.Value); err == nil && n >= 0 {
+			retries = n
+		}
+	}
+	base.StartTasks(workers, retries)
+}
@@ -10,13 +10,25 @@ type Database struct {
 	TablePrefix string `json:"table_prefix"`
 	DBFile      string `json:"db_file"`
 }
+
+// CacheConfig 缓存配置，type为memory、redis或memcached。
+// redis的key都以alist:开头，清空缓存时只删除这些key；memcached清空缓存时更换key的版本，不会清空整个服务器
+type CacheConfig struct {
+	Type       string `json:"type"`
+	Expiration int    `json:"expiration"` // 单位：分钟
+	Address    string `json:"address"`    // memcached多个地址用逗号分隔
+	Password   string `json:"password"`
+	DB         int    `json:"db"` // redis的数据库编号
+}
+
 type Config struct {
-	Address  string   `json:"address"`
-	Port     int      `json:"port"`
-	Database Database `json:"database"`
-	Https    bool     `json:"https"`
-	CertFile string   `json:"cert_file"`
-	KeyFile  string   `json:"key_file"`
+	Address  string      `json:"address"`
+	Port     int         `json:"port"`
+	Database Database    `json:"database"`
+	Https    bool        `json:"https"`
+	CertFile string      `json:"cert_file"`
+	KeyFile  string      `json:"key_file"`
+	Cache    CacheConfig `json:"cache"`
 }
 
 func DefaultConfig() *Config {
@@ -29,5 +41,9 @@ func DefaultConfig() *Config {
 			TablePrefix: "x_",
 			DBFile:      "data/data.db",
 		},
+		Cache: CacheConfig{
+			Type:       "memory",
+			Expiration: 60,
+		},
 	}
 }
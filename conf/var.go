@@ -5,6 +5,7 @@ import (
 	"github.com/eko/gocache/v2/cache"
 	"github.com/robfig/cron/v3"
 	"gorm.io/gorm"
+	"time"
 )
 
 var (
@@ -46,4 +47,6 @@ var (
 	Token       string
 	DavUsername string
 	DavPassword string
+	SignExpire  time.Duration
+	LegacySign  bool
 )
@@ -148,8 +148,8 @@ func (driver Pan123) GetFile(path string, account *model.Account) (*Pan123File,
 	if err != nil {
 		return nil, err
 	}
-	parentFiles_, _ := base.GetCache(dir, account)
-	parentFiles, _ := parentFiles_.([]Pan123File)
+	var parentFiles []Pan123File
+	_ = base.GetCache(dir, account, &parentFiles)
 	for _, file := range parentFiles {
 		if file.FileName == name {
 			if file.Type != conf.FOLDER {
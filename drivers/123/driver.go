@@ -102,10 +102,8 @@ func (driver Pan123) File(path string, account *model.Account) (*model.File, err
 func (driver Pan123) Files(path string, account *model.Account) ([]model.File, error) {
 	path = utils.ParsePath(path)
 	var rawFiles []Pan123File
-	cache, err := base.GetCache(path, account)
-	if err == nil {
-		rawFiles, _ = cache.([]Pan123File)
-	} else {
+	err := base.GetCache(path, account, &rawFiles)
+	if err != nil {
 		file, err := driver.File(path, account)
 		if err != nil {
 			return nil, err
@@ -114,9 +112,7 @@ func (driver Pan123) Files(path string, account *model.Account) ([]model.File, e
 		if err != nil {
 			return nil, err
 		}
-		if len(rawFiles) > 0 {
-			_ = base.SetCache(path, rawFiles, account)
-		}
+		_ = base.SetCache(path, rawFiles, account)
 	}
 	files := make([]model.File, 0)
 	for _, file := range rawFiles {
@@ -164,7 +160,10 @@ func (driver Pan123) Link(path string, account *model.Account) (*base.Link, erro
 		return nil, err
 	}
 	log.Debug(res.String())
-	link := base.Link{}
+	link := base.Link{
+		// 有效期未知，取保守值
+		Expiration: base.ExpireIn(10 * time.Minute),
+	}
 	if res.StatusCode() == 302 {
 		link.Url = res.Header().Get("location")
 	} else {
@@ -203,6 +202,10 @@ func (driver Pan123) Preview(path string, account *model.Account) (interface{},
 	return nil, base.ErrNotSupport
 }
 
+func (driver Pan123) Search(path string, keyword string, account *model.Account) ([]*model.File, error) {
+	return nil, base.ErrNotSupport
+}
+
 func (driver Pan123) MakeDir(path string, account *model.Account) error {
 	dir, name := filepath.Split(path)
 	parentFile, err := driver.File(dir, account)
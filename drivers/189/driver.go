@@ -20,6 +20,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Cloud189 struct{}
@@ -115,10 +116,8 @@ func (driver Cloud189) File(path string, account *model.Account) (*model.File, e
 func (driver Cloud189) Files(path string, account *model.Account) ([]model.File, error) {
 	path = utils.ParsePath(path)
 	var rawFiles []Cloud189File
-	cache, err := base.GetCache(path, account)
-	if err == nil {
-		rawFiles, _ = cache.([]Cloud189File)
-	} else {
+	err := base.GetCache(path, account, &rawFiles)
+	if err != nil {
 		file, err := driver.File(path, account)
 		if err != nil {
 			return nil, err
@@ -127,9 +126,7 @@ func (driver Cloud189) Files(path string, account *model.Account) ([]model.File,
 		if err != nil {
 			return nil, err
 		}
-		if len(rawFiles) > 0 {
-			_ = base.SetCache(path, rawFiles, account)
-		}
+		_ = base.SetCache(path, rawFiles, account)
 	}
 	files := make([]model.File, 0)
 	for _, file := range rawFiles {
@@ -177,7 +174,10 @@ func (driver Cloud189) Link(path string, account *model.Account) (*base.Link, er
 	if err != nil {
 		return nil, err
 	}
-	link := base.Link{}
+	link := base.Link{
+		// 有效期未知，取保守值
+		Expiration: base.ExpireIn(10 * time.Minute),
+	}
 	if res.StatusCode() == 302 {
 		link.Url = res.Header().Get("location")
 	} else {
@@ -216,6 +216,10 @@ func (driver Cloud189) Preview(path string, account *model.Account) (interface{}
 	return nil, base.ErrNotSupport
 }
 
+func (driver Cloud189) Search(path string, keyword string, account *model.Account) ([]*model.File, error) {
+	return nil, base.ErrNotSupport
+}
+
 func (driver Cloud189) MakeDir(path string, account *model.Account) error {
 	dir, name := filepath.Split(path)
 	parent, err := driver.File(dir, account)
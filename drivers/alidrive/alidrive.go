@@ -119,8 +119,8 @@ func (driver AliDrive) GetFile(path string, account *model.Account) (*AliFile, e
 	if err != nil {
 		return nil, err
 	}
-	parentFiles_, _ := base.GetCache(dir, account)
-	parentFiles, _ := parentFiles_.([]AliFile)
+	var parentFiles []AliFile
+	_ = base.GetCache(dir, account, &parentFiles)
 	for _, file := range parentFiles {
 		if file.Name == name {
 			if file.Type == "file" {
@@ -15,6 +15,7 @@ import (
 	"math"
 	"net/http"
 	"path/filepath"
+	"time"
 )
 
 type AliDrive struct{}
@@ -85,16 +86,15 @@ func (driver AliDrive) Save(account *model.Account, old *model.Account) error {
 		Post("https://api.aliyundrive.com/v2/user/get")
 	log.Debugf("user info: %+v", resp)
 	account.DriveId = resp["default_drive_id"].(string)
+	id := account.ID
 	cronId, err := conf.Cron.AddFunc("@every 2h", func() {
-		name := account.Name
-		log.Debugf("ali account name: %s", name)
-		newAccount, ok := model.GetAccount(name)
-		log.Debugf("ali account: %+v", newAccount)
-		if !ok {
-			return
+		log.Debugf("refresh the token of ali account [%d]", id)
+		err := model.RefreshAccountToken(id, func(account *model.Account) error {
+			return driver.RefreshToken(account)
+		})
+		if err != nil {
+			log.Warnf("failed to refresh the token of ali account [%d]: %s", id, err.Error())
 		}
-		err = driver.RefreshToken(&newAccount)
-		_ = model.SaveAccount(&newAccount)
 	})
 	if err != nil {
 		return err
@@ -135,10 +135,8 @@ func (driver AliDrive) File(path string, account *model.Account) (*model.File, e
 func (driver AliDrive) Files(path string, account *model.Account) ([]model.File, error) {
 	path = utils.ParsePath(path)
 	var rawFiles []AliFile
-	cache, err := base.GetCache(path, account)
-	if err == nil {
-		rawFiles, _ = cache.([]AliFile)
-	} else {
+	err := base.GetCache(path, account, &rawFiles)
+	if err != nil {
 		file, err := driver.File(path, account)
 		if err != nil {
 			return nil, err
@@ -147,9 +145,7 @@ func (driver AliDrive) Files(path string, account *model.Account) ([]model.File,
 		if err != nil {
 			return nil, err
 		}
-		if len(rawFiles) > 0 {
-			_ = base.SetCache(path, rawFiles, account)
-		}
+		_ = base.SetCache(path, rawFiles, account)
 	}
 	files := make([]model.File, 0)
 	for _, file := range rawFiles {
@@ -188,9 +184,15 @@ func (driver AliDrive) Link(path string, account *model.Account) (*base.Link, er
 		}
 		return nil, fmt.Errorf("%s", e.Message)
 	}
-	return &base.Link{
+	link := base.Link{
 		Url: resp["url"].(string),
-	}, nil
+	}
+	if expiration, err := time.Parse(time.RFC3339, fmt.Sprint(resp["expiration"])); err == nil {
+		link.Expiration = &expiration
+	} else {
+		link.Expiration = base.ExpireIn(14400 * time.Second)
+	}
+	return &link, nil
 }
 
 func (driver AliDrive) Path(path string, account *model.Account) (*model.File, []model.File, error) {
@@ -259,6 +261,10 @@ func (driver AliDrive) Preview(path string, account *model.Account) (interface{}
 	return resp, nil
 }
 
+func (driver AliDrive) Search(path string, keyword string, account *model.Account) ([]*model.File, error) {
+	return nil, base.ErrNotSupport
+}
+
 func (driver AliDrive) MakeDir(path string, account *model.Account) error {
 	dir, name := filepath.Split(path)
 	parentFile, err := driver.File(dir, account)
@@ -1,9 +1,17 @@
 package alist
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/Xhofe/alist/drivers/base"
 	"github.com/Xhofe/alist/model"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"time"
 )
 
 type BaseResp struct {
@@ -16,6 +24,19 @@ type PathResp struct {
 	Data []model.File `json:"data"`
 }
 
+// FsResp 跨账号的移动复制等操作在后台任务中进行，返回任务id
+type FsResp struct {
+	BaseResp
+	Data struct {
+		TaskId uint `json:"task_id"`
+	} `json:"data"`
+}
+
+type TaskResp struct {
+	BaseResp
+	Data model.Task `json:"data"`
+}
+
 type PreviewResp struct {
 	BaseResp
 	Data interface{} `json:"data"`
@@ -25,16 +46,122 @@ func (driver *Alist) Login(account *model.Account) error {
 	var resp BaseResp
 	_, err := base.RestyClient.R().SetResult(&resp).
 		SetHeader("Authorization", account.AccessToken).
-		Get(account.SiteUrl+"/api/admin/login")
+		Get(account.SiteUrl + "/api/admin/login")
+	if err != nil {
+		return err
+	}
+	if resp.Code != 200 {
+		return errors.New(resp.Message)
+	}
+	return nil
+}
+
+// fs call the write api of the remote alist, and wait for the task if one is started
+func (driver Alist) fs(api string, body base.Json, account *model.Account) error {
+	var resp FsResp
+	_, err := base.RestyClient.R().SetResult(&resp).
+		SetHeader("Authorization", account.AccessToken).
+		SetBody(body).Post(account.SiteUrl + "/api/admin/fs/" + api)
 	if err != nil {
 		return err
 	}
+	return driver.checkFsResp(context.Background(), &resp, account)
+}
+
+func (driver Alist) checkFsResp(ctx context.Context, resp *FsResp, account *model.Account) error {
 	if resp.Code != 200 {
 		return errors.New(resp.Message)
 	}
+	if resp.Data.TaskId != 0 {
+		return driver.waitTask(ctx, resp.Data.TaskId, account)
+	}
 	return nil
 }
 
+const (
+	// WaitTaskTimeout 等待远程任务的最长时间
+	WaitTaskTimeout     = 12 * time.Hour
+	waitTaskMinInterval = 500 * time.Millisecond
+	waitTaskMaxInterval = 10 * time.Second
+)
+
+// waitTask poll the task of the remote alist until it finished, the interval grows from 0.5s to 10s
+func (driver Alist) waitTask(ctx context.Context, id uint, account *model.Account) error {
+	ctx, cancel := context.WithTimeout(ctx, WaitTaskTimeout)
+	defer cancel()
+	interval := waitTaskMinInterval
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait for task [%d] of the remote alist: %w", id, ctx.Err())
+		case <-time.After(interval):
+		}
+		if interval *= 2; interval > waitTaskMaxInterval {
+			interval = waitTaskMaxInterval
+		}
+		var resp TaskResp
+		_, err := base.RestyClient.R().SetContext(ctx).SetResult(&resp).
+			SetHeader("Authorization", account.AccessToken).
+			SetQueryParam("id", strconv.FormatUint(uint64(id), 10)).
+			Get(account.SiteUrl + "/api/admin/task")
+		if err != nil {
+			return err
+		}
+		if resp.Code != 200 {
+			return fmt.Errorf("task [%d] of the remote alist: %s", id, resp.Message)
+		}
+		switch resp.Data.Status {
+		case model.TaskSucceeded:
+			return nil
+		case model.TaskFailed:
+			return errors.New(resp.Data.Error)
+		case model.TaskCancelled:
+			return base.ErrTaskCancelled
+		}
+	}
+}
+
+// upload stream the file to the remote alist with multipart/form-data
+func (driver Alist) upload(file *model.FileStream, dir string, account *model.Account) error {
+	reader, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		// path 需要在文件之前
+		err := form.WriteField("path", dir)
+		if err == nil {
+			var part io.Writer
+			part, err = form.CreateFormFile("file", file.Name)
+			if err == nil {
+				_, err = io.Copy(part, file)
+			}
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		_ = writer.CloseWithError(err)
+	}()
+	req, err := http.NewRequestWithContext(file.Context(), http.MethodPost, account.SiteUrl+"/api/admin/fs/upload", reader)
+	if err != nil {
+		_ = reader.Close()
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", account.AccessToken)
+	res, err := base.RestyClient.GetClient().Do(req)
+	if err != nil {
+		_ = reader.Close()
+		return err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	var resp FsResp
+	if err = json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return fmt.Errorf("upload error: %s", res.Status)
+	}
+	return driver.checkFsResp(file.Context(), &resp, account)
+}
+
 func init() {
 	base.RegisterDriver(&Alist{})
 }
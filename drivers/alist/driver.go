@@ -103,30 +103,28 @@ func (driver Alist) Files(path string, account *model.Account) ([]model.File, er
 
 func (driver Alist) Link(path string, account *model.Account) (*base.Link, error) {
 	path = utils.ParsePath(path)
-	name := utils.Base(path)
+	path = utils.ParsePath(strings.ReplaceAll(filepath.Join(account.RootFolder, path), "\\", "/"))
 	flag := "d"
 	if utils.GetFileType(filepath.Ext(path)) == conf.TEXT {
 		flag = "p"
 	}
 	link := base.Link{}
-	link.Url = fmt.Sprintf("%s/%s%s?sign=%s", account.SiteUrl, flag, path, utils.SignWithToken(name, conf.Token))
+	link.Url = fmt.Sprintf("%s/%s%s?sign=%s", account.SiteUrl, flag, path, utils.SignWithKey(path, account.AccessToken))
 	return &link, nil
 }
 
 func (driver Alist) Path(path string, account *model.Account) (*model.File, []model.File, error) {
 	path = utils.ParsePath(path)
-	path = filepath.Join(account.RootFolder, path)
-	path = strings.ReplaceAll(path, "\\", "/")
-	cache, err := base.GetCache(path, account)
+	var cache []model.File
+	err := base.GetCache(path, account, &cache)
 	if err == nil {
-		files := cache.([]model.File)
-		return nil, files, nil
+		return nil, cache, nil
 	}
 	var resp PathResp
 	_, err = base.RestyClient.R().SetResult(&resp).
 		SetHeader("Authorization", account.AccessToken).
 		SetBody(base.Json{
-			"path": path,
+			"path": utils.Join(account.RootFolder, path),
 		}).Post(account.SiteUrl + "/api/public/path")
 	if err != nil {
 		return nil, nil, err
@@ -137,9 +135,7 @@ func (driver Alist) Path(path string, account *model.Account) (*model.File, []mo
 	if resp.Message == "file" {
 		return &resp.Data[0], nil, nil
 	}
-	if len(resp.Data) > 0 {
-		_ = base.SetCache(path, resp.Data, account)
-	}
+	_ = base.SetCache(path, resp.Data, account)
 	return nil, resp.Data, nil
 }
 
@@ -161,24 +157,38 @@ func (driver Alist) Preview(path string, account *model.Account) (interface{}, e
 	return resp.Data, nil
 }
 
+func (driver Alist) Search(path string, keyword string, account *model.Account) ([]*model.File, error) {
+	return nil, base.ErrNotSupport
+}
+
 func (driver Alist) MakeDir(path string, account *model.Account) error {
-	return base.ErrNotImplement
+	return driver.fs("mkdir", base.Json{
+		"path": utils.Join(account.RootFolder, path),
+	}, account)
 }
 
 func (driver Alist) Move(src string, dst string, account *model.Account) error {
-	return base.ErrNotImplement
+	return driver.fs("move", base.Json{
+		"src": utils.Join(account.RootFolder, src),
+		"dst": utils.Join(account.RootFolder, dst),
+	}, account)
 }
 
 func (driver Alist) Copy(src string, dst string, account *model.Account) error {
-	return base.ErrNotImplement
+	return driver.fs("copy", base.Json{
+		"src": utils.Join(account.RootFolder, src),
+		"dst": utils.Join(account.RootFolder, dst),
+	}, account)
 }
 
 func (driver Alist) Delete(path string, account *model.Account) error {
-	return base.ErrNotImplement
+	return driver.fs("delete", base.Json{
+		"path": utils.Join(account.RootFolder, path),
+	}, account)
 }
 
 func (driver Alist) Upload(file *model.FileStream, account *model.Account) error {
-	return base.ErrNotImplement
+	return driver.upload(file, utils.Join(account.RootFolder, file.ParentPath), account)
 }
 
 var _ base.Driver = (*Alist)(nil)
@@ -11,4 +11,9 @@ import (
 	_ "github.com/Xhofe/alist/drivers/native"
 	_ "github.com/Xhofe/alist/drivers/onedrive"
 	_ "github.com/Xhofe/alist/drivers/pikpak"
+	_ "github.com/Xhofe/alist/drivers/s3"
+	_ "github.com/Xhofe/alist/drivers/sftp"
+	_ "github.com/Xhofe/alist/drivers/smb"
+	_ "github.com/Xhofe/alist/drivers/union"
+	_ "github.com/Xhofe/alist/drivers/webdav"
 )
@@ -1,11 +1,23 @@
 package base
 
 import (
+	"bytes"
+	"encoding/gob"
 	"fmt"
 	"github.com/Xhofe/alist/conf"
 	"github.com/Xhofe/alist/model"
 	"github.com/Xhofe/alist/utils"
+	"github.com/eko/gocache/v2/store"
 	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+const (
+	// NotFoundCacheTime 路径不存在的结果缓存的时间
+	NotFoundCacheTime = 30 * time.Second
+	// LinkExpireAhead 链接在过期前这么长时间就不再使用缓存
+	LinkExpireAhead = time.Minute
 )
 
 func KeyCache(path string, account *model.Account) string {
@@ -13,16 +25,279 @@ func KeyCache(path string, account *model.Account) string {
 	return fmt.Sprintf("%s%s", account.Name, path)
 }
 
+func keyNotFound(path string, account *model.Account) string {
+	return "404:" + KeyCache(path, account)
+}
+
+func keyLink(path string, account *model.Account) string {
+	return "link:" + KeyCache(path, account)
+}
+
+func keyIndex(path string, account *model.Account) string {
+	return "index:" + KeyCache(path, account)
+}
+
+// cacheIndex 每个路径一个索引，记录这个路径的缓存key和有缓存的子路径，用于递归删除缓存。
+// 索引和缓存保存在同一个地方，记录的是过期时间，过期的记录在写入索引时清理
+type cacheIndex struct {
+	Keys     map[string]time.Time
+	Children map[string]time.Time // 子路径下的缓存最晚过期的时间
+}
+
+// cacheIndexLock 串行化索引的读写，索引的读改写不是原子的
+var cacheIndexLock sync.Mutex
+
+func getIndex(path string, account *model.Account) *cacheIndex {
+	index := &cacheIndex{Keys: map[string]time.Time{}, Children: map[string]time.Time{}}
+	if value, err := conf.Cache.Get(conf.Ctx, keyIndex(path, account)); err == nil {
+		_ = decodeCache(value, index)
+	}
+	return index
+}
+
+// saveIndex drop the expired records and save the index until the last record expires
+func saveIndex(path string, account *model.Account, index *cacheIndex) {
+	now := time.Now()
+	last := now
+	for _, m := range []map[string]time.Time{index.Keys, index.Children} {
+		for k, t := range m {
+			if !t.After(now) {
+				delete(m, k)
+			} else if t.After(last) {
+				last = t
+			}
+		}
+	}
+	key := keyIndex(path, account)
+	if len(index.Keys) == 0 && len(index.Children) == 0 {
+		_ = conf.Cache.Delete(conf.Ctx, key)
+		return
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(index); err != nil {
+		return
+	}
+	_ = conf.Cache.Set(conf.Ctx, key, buf.Bytes(), &store.Options{Expiration: last.Sub(now)})
+}
+
+// setIndexed set the cache and add it to the index of the path, the parent folders link to the path
+// until one of them already links to it long enough
+func setIndexed(key string, value []byte, expiration time.Duration, path string, account *model.Account) error {
+	cacheIndexLock.Lock()
+	defer cacheIndexLock.Unlock()
+	if err := conf.Cache.Set(conf.Ctx, key, value, &store.Options{Expiration: expiration}); err != nil {
+		return err
+	}
+	expire := time.Now().Add(expiration)
+	path = utils.ParsePath(path)
+	index := getIndex(path, account)
+	index.Keys[key] = expire
+	saveIndex(path, account, index)
+	for path != "/" {
+		parent := utils.Dir(path)
+		index = getIndex(parent, account)
+		if !index.Children[path].Before(expire) {
+			break
+		}
+		index.Children[path] = expire
+		saveIndex(parent, account, index)
+		path = parent
+	}
+	return nil
+}
+
+// deleteIndexed delete the caches of the path and all the paths under it, must be called with cacheIndexLock held
+func deleteIndexed(path string, account *model.Account) {
+	index := getIndex(path, account)
+	for key := range index.Keys {
+		_ = conf.Cache.Delete(conf.Ctx, key)
+	}
+	for child := range index.Children {
+		deleteIndexed(child, account)
+	}
+	_ = conf.Cache.Delete(conf.Ctx, keyIndex(path, account))
+}
+
+// CacheTime get the time the folders of the account stay fresh in cache
+func CacheTime(account *model.Account) time.Duration {
+	if account.CacheTime > 0 {
+		return time.Duration(account.CacheTime) * time.Minute
+	}
+	return time.Duration(conf.Conf.Cache.Expiration) * time.Minute
+}
+
+// cacheEntry 过期后在后台刷新完成前仍返回旧的值，所以实际保存的时间是缓存时间的两倍
+type cacheEntry struct {
+	Time time.Time
+	Data []byte
+}
+
+// refreshing 正在后台刷新的缓存，值为刷新期间是否写入了新的缓存
+var refreshing = map[string]bool{}
+var refreshingLock sync.Mutex
+
+func encodeEntry(obj interface{}, t time.Time) ([]byte, error) {
+	var data bytes.Buffer
+	if err := gob.NewEncoder(&data).Encode(obj); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(cacheEntry{Time: t, Data: data.Bytes()}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SetCache 缓存的值使用gob序列化，以便保存到redis、memcached等外部缓存
 func SetCache(path string, obj interface{}, account *model.Account) error {
-	return conf.Cache.Set(conf.Ctx, KeyCache(path, account), obj, nil)
+	data, err := encodeEntry(obj, time.Now())
+	if err != nil {
+		return err
+	}
+	key := KeyCache(path, account)
+	refreshingLock.Lock()
+	if _, ok := refreshing[key]; ok {
+		refreshing[key] = true
+	}
+	refreshingLock.Unlock()
+	_ = conf.Cache.Delete(conf.Ctx, keyNotFound(path, account))
+	return setIndexed(key, data, 2*CacheTime(account), path, account)
+}
+
+func decodeCache(value interface{}, obj interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unknown cache value type: %T", value)
+	}
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(obj)
+}
+
+// GetCache decode the cache of the path into obj, which must be a pointer.
+// The expired cache is still returned while it's refreshed in the background.
+func GetCache(path string, account *model.Account, obj interface{}) error {
+	key := KeyCache(path, account)
+	if account.NoCache {
+		return fmt.Errorf("cache of [%s] is bypassed", key)
+	}
+	value, err := conf.Cache.Get(conf.Ctx, key)
+	if err != nil {
+		return err
+	}
+	var entry cacheEntry
+	if err = decodeCache(value, &entry); err != nil {
+		return err
+	}
+	if time.Since(entry.Time) > CacheTime(account) {
+		revalidate(key, path, account)
+	}
+	return gob.NewDecoder(bytes.NewReader(entry.Data)).Decode(obj)
+}
+
+// revalidate start refreshing the expired cache if not yet, the refresh reads with NoCache to get the new data
+func revalidate(key string, path string, account *model.Account) {
+	refreshingLock.Lock()
+	defer refreshingLock.Unlock()
+	if _, ok := refreshing[key]; ok {
+		return
+	}
+	acc := *account
+	acc.NoCache = true
+	refreshing[key] = false
+	go refreshCache(key, path, &acc)
 }
 
-func GetCache(path string, account *model.Account) (interface{}, error) {
-	return conf.Cache.Get(conf.Ctx, KeyCache(path, account))
+func refreshCache(key string, path string, account *model.Account) {
+	var err error
+	// 不经过fetchGroup，否则会加入正在进行的、读到旧缓存的请求
+	if driver, ok := GetDriver(account.Type); ok {
+		_, err = fetchFiles(driver, account, path)
+	}
+	refreshingLock.Lock()
+	set := refreshing[key]
+	delete(refreshing, key)
+	refreshingLock.Unlock()
+	if err != nil {
+		log.Debugf("failed to refresh cache of %s: %s", key, err.Error())
+	}
+	// 出错或者驱动没有缓存这个路径，删除旧的缓存
+	if !set {
+		_ = conf.Cache.Delete(conf.Ctx, key)
+	}
 }
 
 func DeleteCache(path string, account *model.Account) error {
+	_ = conf.Cache.Delete(conf.Ctx, keyNotFound(path, account))
+	_ = conf.Cache.Delete(conf.Ctx, keyLink(path, account))
 	err := conf.Cache.Delete(conf.Ctx, KeyCache(path, account))
 	log.Debugf("delete cache %s: %+v", path, err)
 	return err
 }
+
+// InvalidateCache delete the cache of the path and its parent folder, and the caches of all the paths under it if recursive
+func InvalidateCache(path string, account *model.Account, recursive bool) error {
+	path = utils.ParsePath(path)
+	if path != "/" {
+		_ = DeleteCache(utils.Dir(path), account)
+	}
+	if !recursive {
+		return DeleteCache(path, account)
+	}
+	cacheIndexLock.Lock()
+	defer cacheIndexLock.Unlock()
+	deleteIndexed(path, account)
+	if path != "/" {
+		parent := utils.Dir(path)
+		index := getIndex(parent, account)
+		delete(index.Children, path)
+		saveIndex(parent, account, index)
+	}
+	log.Debugf("invalidate cache %s", path)
+	return nil
+}
+
+// SetNotFound 短暂缓存路径不存在的结果，避免反复请求不存在的路径
+func SetNotFound(path string, account *model.Account) error {
+	return setIndexed(keyNotFound(path, account), []byte{1}, NotFoundCacheTime, path, account)
+}
+
+func IsNotFound(path string, account *model.Account) bool {
+	_, err := conf.Cache.Get(conf.Ctx, keyNotFound(path, account))
+	return err == nil
+}
+
+// setLinkCache cache the link until shortly before it expires but no longer than the cache time of the account,
+// the links without expiration or read locally are not cached
+func setLinkCache(path string, link *Link, account *model.Account) error {
+	if link.Expiration == nil || link.Reader != nil {
+		return nil
+	}
+	expiration := time.Until(*link.Expiration) - LinkExpireAhead
+	if expiration <= 0 {
+		return nil
+	}
+	if cacheTime := CacheTime(account); expiration > cacheTime {
+		expiration = cacheTime
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(link); err != nil {
+		return err
+	}
+	return setIndexed(keyLink(path, account), buf.Bytes(), expiration, path, account)
+}
+
+func getLinkCache(path string, account *model.Account) (*Link, error) {
+	value, err := conf.Cache.Get(conf.Ctx, keyLink(path, account))
+	if err != nil {
+		return nil, err
+	}
+	var link Link
+	if err = decodeCache(value, &link); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
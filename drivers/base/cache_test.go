@@ -0,0 +1,159 @@
+package base
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Xhofe/alist/conf"
+	"github.com/Xhofe/alist/model"
+	"github.com/eko/gocache/v2/cache"
+	"github.com/eko/gocache/v2/store"
+	goCache "github.com/patrickmn/go-cache"
+)
+
+func initCacheTest(t *testing.T) *goCache.Cache {
+	client := goCache.New(time.Hour, time.Hour)
+	conf.Cache = cache.New(store.NewGoCache(client, nil))
+	return client
+}
+
+func cached(path string, account *model.Account) bool {
+	_, err := conf.Cache.Get(conf.Ctx, KeyCache(path, account))
+	return err == nil
+}
+
+// 并发写入缓存后递归删除，只删除路径下的缓存，索引随之清理
+func TestInvalidateCache(t *testing.T) {
+	client := initCacheTest(t)
+	account := &model.Account{Name: "cache", CacheTime: 1}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for _, dir := range []string{"/a", "/a/b", "/c"} {
+				path := fmt.Sprintf("%s/%d", dir, i)
+				if err := SetCache(path, []model.File{{Name: path}}, account); err != nil {
+					t.Error(err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if !cached("/a/b/7", account) || !cached("/c/7", account) {
+		t.Fatal("cache not set")
+	}
+	if err := InvalidateCache("/a", account, true); err != nil {
+		t.Fatal(err)
+	}
+	if cached("/a/7", account) || cached("/a/b/7", account) {
+		t.Errorf("the caches under /a are not deleted")
+	}
+	if !cached("/c/7", account) {
+		t.Errorf("the caches of /c are deleted")
+	}
+	if children := getIndex("/", account).Children; len(children) != 1 {
+		t.Errorf("children of / = %v, want only /c", children)
+	}
+	if err := InvalidateCache("/", account, true); err != nil {
+		t.Fatal(err)
+	}
+	if n := client.ItemCount(); n != 0 {
+		t.Errorf("%d items left after invalidating /", n)
+	}
+}
+
+type refreshDriver struct {
+	Driver
+	calls int32
+}
+
+func (d *refreshDriver) Config() DriverConfig {
+	return DriverConfig{Name: "RefreshTest"}
+}
+
+func (d *refreshDriver) Files(path string, account *model.Account) ([]model.File, error) {
+	var files []model.File
+	if err := GetCache(path, account, &files); err == nil {
+		return files, nil
+	}
+	atomic.AddInt32(&d.calls, 1)
+	_ = SetCache(path, files, account)
+	return files, nil
+}
+
+// 过期的缓存在后台刷新，刷新时的读取不使用缓存，刷新得到的空目录也被缓存
+func TestRefreshCache(t *testing.T) {
+	initCacheTest(t)
+	driver := &refreshDriver{}
+	RegisterDriver(driver)
+	account := &model.Account{Name: "refresh", Type: "RefreshTest", CacheTime: 1}
+	data, err := encodeEntry([]model.File{{Name: "old"}}, time.Now().Add(-2*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = conf.Cache.Set(conf.Ctx, KeyCache("/a", account), data, nil); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := driver.Files("/a", account)
+	if len(files) != 1 || files[0].Name != "old" {
+		t.Fatalf("files = %v, want the expired cache", files)
+	}
+	for i := 0; ; i++ {
+		refreshingLock.Lock()
+		n := len(refreshing)
+		refreshingLock.Unlock()
+		if n == 0 {
+			break
+		}
+		if i > 100 {
+			t.Fatal("cache not refreshed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for i := 0; i < 2; i++ {
+		if files, _ = driver.Files("/a", account); len(files) != 0 {
+			t.Errorf("files = %v, want the refreshed empty folder", files)
+		}
+	}
+	if calls := atomic.LoadInt32(&driver.calls); calls != 1 {
+		t.Errorf("driver called %d times, want 1", calls)
+	}
+}
+
+// 链接缓存到过期前，但不超过账号的缓存时间
+func TestSetLinkCache(t *testing.T) {
+	client := initCacheTest(t)
+	account := &model.Account{Name: "link", CacheTime: 10}
+	tests := []struct {
+		name   string
+		expire time.Duration
+		want   time.Duration
+	}{
+		{"long", 4 * time.Hour, 10 * time.Minute},
+		{"short", 5 * time.Minute, 4 * time.Minute},
+		{"expiring", 30 * time.Second, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := "/" + tt.name
+			link := &Link{Url: "http://example.com" + path, Expiration: ExpireIn(tt.expire)}
+			if err := setLinkCache(path, link, account); err != nil {
+				t.Fatal(err)
+			}
+			_, expiration, ok := client.GetWithExpiration(keyLink(path, account))
+			if !ok {
+				if tt.want != 0 {
+					t.Fatal("link not cached")
+				}
+				return
+			}
+			if ttl := time.Until(expiration); ttl > tt.want || ttl < tt.want-time.Second {
+				t.Errorf("ttl = %s, want %s", ttl, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/utils"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DownloadIdleTimeout 离线下载超过这个时间没有收到数据则视为失败，由任务重试
+	DownloadIdleTimeout = time.Minute
+)
+
+var ErrForbiddenAddress = errors.New("the address is not allowed")
+
+// forbiddenNets 不是ip的方法能判断的内网地址
+var forbiddenNets = []*net.IPNet{
+	{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}, // CGNAT
+}
+
+// isForbiddenIP 离线下载不能访问本机和内网的地址
+func isForbiddenIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, n := range forbiddenNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+var downloadDialer = &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+
+// safeDialContext resolve the host and only dial the public addresses, the resolved address
+// is dialed directly so that the host can't be rebound to another address between them
+func safeDialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		if isForbiddenIP(ip.IP) {
+			return nil, fmt.Errorf("%w: %s(%s)", ErrForbiddenAddress, host, ip.IP)
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no address of %s", host)
+	}
+	return downloadDialer.DialContext(ctx, network, net.JoinHostPort(ips[0].IP.String(), port))
+}
+
+// downloadClient 离线下载使用的客户端，重定向也经过同样的检查，不使用代理以免绕过检查
+var downloadClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext:           safeDialContext,
+		TLSHandshakeTimeout:   30 * time.Second,
+		ResponseHeaderTimeout: time.Minute,
+		IdleConnTimeout:       90 * time.Second,
+	},
+}
+
+// idleReader cancel the request if no data is read in DownloadIdleTimeout
+type idleReader struct {
+	io.ReadCloser
+	timer *time.Timer
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.timer.Reset(DownloadIdleTimeout)
+	return n, err
+}
+
+// DownloadTempFile the file the offline download task is saved to before uploaded
+func DownloadTempFile(id uint) string {
+	return filepath.Join(TaskTempDir(), fmt.Sprintf("download-%d", id))
+}
+
+// Download 离线下载，先将url的内容保存到任务的临时文件，再上传到目标账号。
+// 重试或重启后从临时文件已有的大小继续下载
+func Download(ctx context.Context, task *model.Task, driver Driver, account *model.Account, progress *Progress) error {
+	if err := os.MkdirAll(TaskTempDir(), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(DownloadTempFile(task.ID), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	size, mimeType, err := downloadTo(ctx, task.SrcPath, file, progress)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(path.Ext(task.DstPath))
+	}
+	// 进度按上传重新计算
+	atomic.StoreInt64(&progress.Done, 0)
+	atomic.StoreInt64(&progress.Total, size)
+	fileStream := model.FileStream{
+		File:       &progressReader{ReadCloser: file, ctx: ctx, done: &progress.Done},
+		Size:       uint64(size),
+		ParentPath: utils.Dir(task.DstPath),
+		Name:       utils.Base(task.DstPath),
+		MIMEType:   mimeType,
+		Ctx:        ctx,
+	}
+	return DoUpload(driver, account, &fileStream)
+}
+
+// parseContentRange parse the Content-Range header like "bytes 0-99/1000" or "bytes */1000",
+// start is -1 for unsatisfied range and total is -1 if unknown
+func parseContentRange(header string) (start int64, total int64, ok bool) {
+	if !strings.HasPrefix(header, "bytes ") {
+		return 0, 0, false
+	}
+	header = strings.TrimPrefix(header, "bytes ")
+	idx := strings.Index(header, "/")
+	if idx == -1 {
+		return 0, 0, false
+	}
+	total = -1
+	if header[idx+1:] != "*" {
+		var err error
+		if total, err = strconv.ParseInt(header[idx+1:], 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	rng := header[:idx]
+	if rng == "*" {
+		return -1, total, true
+	}
+	idx = strings.Index(rng, "-")
+	if idx == -1 {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(rng[:idx], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, total, true
+}
+
+func requestFrom(ctx context.Context, url string, offset int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	return downloadClient.Do(req)
+}
+
+// downloadTo download the url to the end of the file, return the size of the whole file.
+// The file is downloaded again if the server returns a range not matching the file
+func downloadTo(ctx context.Context, url string, file *os.File, progress *Progress) (int64, string, error) {
+	written, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, "", err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var res *http.Response
+	for res == nil {
+		res, err = requestFrom(ctx, url, written)
+		if err != nil {
+			return 0, "", err
+		}
+		start, total, ok := parseContentRange(res.Header.Get("Content-Range"))
+		switch {
+		case res.StatusCode == http.StatusPartialContent && ok && start == written:
+			log.Debugf("resume downloading [%s] from %d", url, written)
+		case res.StatusCode == http.StatusRequestedRangeNotSatisfiable && written > 0 && ok && total == written:
+			// 上次已经下载完成
+			_ = res.Body.Close()
+			return written, "", nil
+		case res.StatusCode == http.StatusOK:
+			// 不支持断点续传，重新下载
+			if err = file.Truncate(0); err != nil {
+				_ = res.Body.Close()
+				return 0, "", err
+			}
+			written = 0
+		case written > 0 && (res.StatusCode == http.StatusPartialContent || res.StatusCode == http.StatusRequestedRangeNotSatisfiable):
+			// 返回的范围和已下载的部分对不上，重新下载
+			log.Warnf("range of [%s] mismatch: %s, download again", url, res.Header.Get("Content-Range"))
+			_ = res.Body.Close()
+			res = nil
+			if err = file.Truncate(0); err != nil {
+				return 0, "", err
+			}
+			written = 0
+		default:
+			_ = res.Body.Close()
+			return 0, "", fmt.Errorf("download [%s] error: %s", url, res.Status)
+		}
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if _, err = file.Seek(written, io.SeekStart); err != nil {
+		return 0, "", err
+	}
+	atomic.StoreInt64(&progress.Done, written)
+	if res.ContentLength >= 0 {
+		atomic.StoreInt64(&progress.Total, written+res.ContentLength)
+	}
+	timer := time.AfterFunc(DownloadIdleTimeout, cancel)
+	defer timer.Stop()
+	body := &progressReader{ReadCloser: &idleReader{ReadCloser: res.Body, timer: timer}, ctx: ctx, done: &progress.Done}
+	n, err := io.Copy(file, body)
+	if err != nil {
+		return 0, "", err
+	}
+	return written + n, res.Header.Get("Content-Type"), nil
+}
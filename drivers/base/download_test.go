@@ -0,0 +1,80 @@
+package base
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Xhofe/alist/conf"
+)
+
+// 断点续传时检查返回的范围，和已下载的部分对不上时重新下载
+func TestDownloadTo(t *testing.T) {
+	const content = "hello world"
+	tests := []struct {
+		name    string
+		partial string
+		handler func(w http.ResponseWriter, r *http.Request)
+	}{
+		{"resume", "hello", func(w http.ResponseWriter, r *http.Request) {
+			http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+		}},
+		{"no range support", "xx", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(content))
+		}},
+		{"wrong range", "hello", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Range") == "" {
+				_, _ = w.Write([]byte(content))
+				return
+			}
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", len(content)-1, len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = w.Write([]byte(content))
+		}},
+		{"completed", content, func(w http.ResponseWriter, r *http.Request) {
+			http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+		}},
+		{"longer than total", content + "!!", func(w http.ResponseWriter, r *http.Request) {
+			http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(tt.handler))
+			defer server.Close()
+			client := downloadClient
+			downloadClient = server.Client()
+			defer func() {
+				downloadClient = client
+			}()
+			name := filepath.Join(t.TempDir(), "download")
+			if err := ioutil.WriteFile(name, []byte(tt.partial), 0600); err != nil {
+				t.Fatal(err)
+			}
+			file, err := os.OpenFile(name, os.O_RDWR, 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				_ = file.Close()
+			}()
+			size, _, err := downloadTo(conf.Ctx, server.URL, file, &Progress{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := ioutil.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != content || size != int64(len(content)) {
+				t.Errorf("downloaded %q, size %d", data, size)
+			}
+		})
+	}
+}
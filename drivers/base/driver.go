@@ -24,8 +24,7 @@ type Driver interface {
 	Path(path string, account *model.Account) (*model.File, []model.File, error)
 	Proxy(c *gin.Context, account *model.Account)
 	Preview(path string, account *model.Account) (interface{}, error)
-	// TODO
-	//Search(path string, keyword string, account *model.Account) ([]*model.File, error)
+	Search(path string, keyword string, account *model.Account) ([]*model.File, error)
 	MakeDir(path string, account *model.Account) error
 	Move(src string, dst string, account *model.Account) error
 	Copy(src string, dst string, account *model.Account) error
@@ -85,11 +84,29 @@ func GetDrivers() map[string][]Item {
 			}, v.Items()...)
 		}
 		res[k] = append(res[k], Item{
+			Name:        "mount_path",
+			Label:       "mount path",
+			Type:        TypeString,
+			Required:    false,
+			Description: "the path to mount the account, such as /media/movies, default /name",
+		}, Item{
 			Name:        "proxy_url",
 			Label:       "proxy_url",
 			Type:        TypeString,
 			Required:    false,
 			Description: "proxy url",
+		}, Item{
+			Name:        "search",
+			Label:       "search",
+			Type:        TypeBool,
+			Required:    false,
+			Description: "allow search files in this account",
+		}, Item{
+			Name:        "cache_time",
+			Label:       "cache time",
+			Type:        TypeNumber,
+			Required:    false,
+			Description: "minutes to cache the folders, default the expiration in config",
 		})
 	}
 	return res
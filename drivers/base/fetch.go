@@ -0,0 +1,157 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Xhofe/alist/conf"
+	"github.com/Xhofe/alist/model"
+	"golang.org/x/sync/singleflight"
+	"net"
+	"net/http"
+	"time"
+)
+
+// 读操作的统一入口，同一账号同一路径的并发请求共用一次driver调用，路径不存在的结果会被短暂缓存
+
+var fetchGroup singleflight.Group
+
+func fetchKey(op string, account *model.Account, path string) string {
+	return op + ":" + KeyCache(path, account)
+}
+
+// copyFiles 每个调用者得到各自的副本，互不影响
+func copyFiles(files []model.File) []model.File {
+	if files == nil {
+		return nil
+	}
+	return append(make([]model.File, 0, len(files)), files...)
+}
+
+func fetchFiles(driver Driver, account *model.Account, path string) ([]model.File, error) {
+	if IsNotFound(path, account) {
+		return nil, ErrPathNotFound
+	}
+	files, err := driver.Files(path, account)
+	if errors.Is(err, ErrPathNotFound) {
+		_ = SetNotFound(path, account)
+	}
+	return files, err
+}
+
+func GetFile(driver Driver, account *model.Account, path string) (*model.File, error) {
+	v, err, _ := fetchGroup.Do(fetchKey("file", account, path), func() (interface{}, error) {
+		if IsNotFound(path, account) {
+			return nil, ErrPathNotFound
+		}
+		file, err := driver.File(path, account)
+		if errors.Is(err, ErrPathNotFound) {
+			_ = SetNotFound(path, account)
+		}
+		return file, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if v.(*model.File) == nil {
+		return nil, nil
+	}
+	file := *v.(*model.File)
+	return &file, nil
+}
+
+func GetFiles(driver Driver, account *model.Account, path string) ([]model.File, error) {
+	v, err, _ := fetchGroup.Do(fetchKey("files", account, path), func() (interface{}, error) {
+		return fetchFiles(driver, account, path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyFiles(v.([]model.File)), nil
+}
+
+type pathResult struct {
+	file  *model.File
+	files []model.File
+}
+
+func GetPath(driver Driver, account *model.Account, path string) (*model.File, []model.File, error) {
+	v, err, _ := fetchGroup.Do(fetchKey("path", account, path), func() (interface{}, error) {
+		if IsNotFound(path, account) {
+			return nil, ErrPathNotFound
+		}
+		file, files, err := driver.Path(path, account)
+		if errors.Is(err, ErrPathNotFound) {
+			_ = SetNotFound(path, account)
+		}
+		return pathResult{file: file, files: files}, err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	res := v.(pathResult)
+	if res.file != nil {
+		file := *res.file
+		return &file, nil, nil
+	}
+	return nil, copyFiles(res.files), nil
+}
+
+func GetLink(driver Driver, account *model.Account, path string) (*Link, error) {
+	v, err, _ := fetchGroup.Do(fetchKey("link", account, path), func() (interface{}, error) {
+		if link, err := getLinkCache(path, account); err == nil {
+			return link, nil
+		}
+		link, err := driver.Link(path, account)
+		if err == nil && link != nil {
+			_ = setLinkCache(path, link, account)
+		}
+		return link, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if v.(*Link) == nil {
+		return nil, nil
+	}
+	link := *v.(*Link)
+	return &link, nil
+}
+
+// RefreshLink drop the cached link and get a new one, used when the link expired earlier than expected
+func RefreshLink(driver Driver, account *model.Account, path string) (*Link, error) {
+	_ = conf.Cache.Delete(conf.Ctx, keyLink(path, account))
+	return GetLink(driver, account, path)
+}
+
+// IsLinkExpired 上游对过期的链接一般返回403或410
+func IsLinkExpired(statusCode int) bool {
+	return statusCode == http.StatusForbidden || statusCode == http.StatusGone
+}
+
+// linkClient 请求链接的数据，读取数据的时间不确定，所以只限制连接和等待响应头的时间
+var linkClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		TLSHandshakeTimeout:   30 * time.Second,
+		ResponseHeaderTimeout: time.Minute,
+		IdleConnTimeout:       90 * time.Second,
+	},
+}
+
+// RequestLink get the data of the link from the offset with the headers of the link,
+// the request is canceled with the ctx
+func RequestLink(ctx context.Context, link *Link, offset int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.Url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, header := range link.Headers {
+		req.Header.Set(header.Name, header.Value)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	return linkClient.Do(req)
+}
@@ -0,0 +1,28 @@
+package base
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// 上游没有响应时，取消ctx可以中断请求
+func TestRequestLinkCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	res, err := RequestLink(ctx, &Link{Url: server.URL}, 0)
+	if err == nil {
+		_ = res.Body.Close()
+		t.Fatal("request not canceled")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("canceled after %s", elapsed)
+	}
+}
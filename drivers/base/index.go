@@ -0,0 +1,136 @@
+package base
+
+import (
+	"errors"
+	"github.com/Xhofe/alist/conf"
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/utils"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+var indexing = map[uint]bool{}
+var indexLock sync.Mutex
+
+func IsIndexing(accountId uint) bool {
+	indexLock.Lock()
+	defer indexLock.Unlock()
+	return indexing[accountId]
+}
+
+func sameTime(a, b *model.SearchFile) bool {
+	if a.Modified == nil || b.Modified == nil {
+		return a.Modified == b.Modified
+	}
+	return a.Modified.Equal(*b.Modified)
+}
+
+// BuildIndex crawl the account and update the search index incrementally,
+// all the records are rewritten if rebuild, and the old ones are deleted after the crawl finished
+func BuildIndex(account *model.Account, rebuild bool) error {
+	indexLock.Lock()
+	if indexing[account.ID] {
+		indexLock.Unlock()
+		return ErrIndexing
+	}
+	indexing[account.ID] = true
+	indexLock.Unlock()
+	defer func() {
+		indexLock.Lock()
+		delete(indexing, account.ID)
+		indexLock.Unlock()
+	}()
+	driver, ok := GetDriver(account.Type)
+	if !ok {
+		return errors.New("no driver")
+	}
+	generation := time.Now().UnixNano()
+	log.Infof("start index account: %s", account.Name)
+	dirs := []string{"/"}
+	count := 0
+	for len(dirs) > 0 {
+		dir := dirs[0]
+		dirs = dirs[1:]
+		files, err := GetFiles(driver, account, dir)
+		if err != nil {
+			// 列目录失败时保留旧的索引
+			log.Warnf("index [%s] in account [%s] error: %s", dir, account.Name, err.Error())
+			continue
+		}
+		olds, err := model.GetSearchFiles(account.ID, dir)
+		if err != nil {
+			return err
+		}
+		oldMap := make(map[string]model.SearchFile, len(olds))
+		for _, old := range olds {
+			oldMap[old.Name] = old
+		}
+		changed := make([]model.SearchFile, 0)
+		for _, file := range files {
+			if file.IsDir() {
+				dirs = append(dirs, utils.Join(dir, file.Name))
+			}
+			sf := model.SearchFile{
+				AccountId: account.ID,
+				Path:      dir,
+				Name:      file.Name,
+				Size:      file.Size,
+				Type:      file.Type,
+				Modified:  file.UpdatedAt,
+				// 增量更新时也写入批次，避免被之后的重建误删
+				Generation: generation,
+			}
+			old, ok := oldMap[file.Name]
+			delete(oldMap, file.Name)
+			if ok {
+				if !rebuild && old.Size == sf.Size && old.Type == sf.Type && sameTime(&old, &sf) {
+					continue
+				}
+				sf.ID = old.ID
+			}
+			changed = append(changed, sf)
+		}
+		if err = model.SaveSearchFiles(changed); err != nil {
+			return err
+		}
+		count += len(changed)
+		// 删除已经不存在的文件
+		for _, old := range oldMap {
+			if err = model.DeleteSearchFile(old); err != nil {
+				return err
+			}
+			if old.Type == conf.FOLDER {
+				if err = model.DeleteSearchFilesUnder(account.ID, utils.Join(dir, old.Name)); err != nil {
+					return err
+				}
+			}
+			count++
+		}
+	}
+	if rebuild {
+		if err := model.DeleteSearchFilesBefore(account.ID, generation); err != nil {
+			return err
+		}
+	}
+	log.Infof("finish index account: %s, %d changed", account.Name, count)
+	return nil
+}
+
+// IndexAccounts update the search index of all the accounts allowed search
+func IndexAccounts() {
+	accounts, err := model.GetAccounts()
+	if err != nil {
+		log.Errorf("failed get accounts: %s", err.Error())
+		return
+	}
+	for _, v := range accounts {
+		account, ok := model.GetAccount(v.Name)
+		if !ok || !account.Search {
+			continue
+		}
+		if err = BuildIndex(&account, false); err != nil {
+			log.Errorf("index account [%s] error: %s", account.Name, err.Error())
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package base
+
+import (
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/utils"
+)
+
+// 写操作的统一入口，操作成功后删除受影响目录的缓存，以及新路径不存在的缓存
+
+func DoMakeDir(driver Driver, account *model.Account, path string) error {
+	err := driver.MakeDir(path, account)
+	if err == nil {
+		_ = DeleteCache(utils.Dir(path), account)
+		_ = DeleteCache(path, account)
+	}
+	return err
+}
+
+func DoMove(driver Driver, account *model.Account, src string, dst string) error {
+	err := driver.Move(src, dst, account)
+	if err == nil {
+		_ = DeleteCache(utils.Dir(src), account)
+		_ = DeleteCache(utils.Dir(dst), account)
+		_ = InvalidateCache(src, account, true)
+		_ = InvalidateCache(dst, account, true)
+	}
+	return err
+}
+
+func DoCopy(driver Driver, account *model.Account, src string, dst string) error {
+	err := driver.Copy(src, dst, account)
+	if err == nil {
+		_ = DeleteCache(utils.Dir(dst), account)
+		_ = InvalidateCache(dst, account, true)
+	}
+	return err
+}
+
+func DoDelete(driver Driver, account *model.Account, path string) error {
+	err := driver.Delete(path, account)
+	if err == nil {
+		_ = DeleteCache(utils.Dir(path), account)
+		_ = InvalidateCache(path, account, true)
+	}
+	return err
+}
+
+func DoUpload(driver Driver, account *model.Account, file *model.FileStream) error {
+	err := driver.Upload(file, account)
+	if err == nil {
+		_ = DeleteCache(file.ParentPath, account)
+		_ = DeleteCache(utils.Join(file.ParentPath, file.Name), account)
+	}
+	return err
+}
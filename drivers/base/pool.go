@@ -0,0 +1,233 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Xhofe/alist/model"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// 基于连接的驱动(ftp、smb等)共用的连接池，每个账号一个
+
+const (
+	DefaultMaxConnections = 5
+	DefaultIdleTimeout    = time.Minute
+	// PoolWaitTimeout 连接数达到上限时等待空闲连接的最长时间
+	PoolWaitTimeout = 30 * time.Second
+	// 空闲超过这个时间的连接在复用前先检查
+	poolCheckAfter = 10 * time.Second
+)
+
+var ErrPoolTimeout = errors.New("timeout waiting for a free connection")
+
+// PoolOptions the callbacks to handle the connections of the pool
+type PoolOptions struct {
+	Dial   func() (interface{}, error)
+	Close  func(conn interface{})
+	Ping   func(conn interface{}) error
+	Broken func(err error) bool // the connection can't be used after the error, err is not nil
+}
+
+type poolConn struct {
+	conn  interface{}
+	since time.Time
+}
+
+// Pool 读取文件的流会长时间占用连接，所以流最多使用max-1个连接，留下一个给列目录等操作
+type Pool struct {
+	sync.Mutex
+	PoolOptions
+	name        string
+	key         string // 账号的连接信息，变化后重建连接池
+	idleTimeout time.Duration
+	idle        []poolConn
+	closed      bool
+	sem         chan struct{} // 限制同时打开的连接数
+	streams     chan struct{} // 限制流打开的连接数
+}
+
+var pools = map[uint]*Pool{}
+var poolsLock sync.Mutex
+
+// GetPool get the pool of the account, the pool is rebuilt if the key(the connection info) changed
+func GetPool(account *model.Account, key string, options func() PoolOptions) *Pool {
+	max := account.MaxConnections
+	if max <= 0 {
+		max = DefaultMaxConnections
+	}
+	idleTimeout := time.Duration(account.IdleTimeout) * time.Second
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultIdleTimeout
+	}
+	key = fmt.Sprintf("%s\n%d\n%s", key, max, idleTimeout)
+	poolsLock.Lock()
+	defer poolsLock.Unlock()
+	pool, ok := pools[account.ID]
+	if ok && pool.key == key {
+		return pool
+	}
+	if ok {
+		pool.close()
+	}
+	streams := max - 1
+	if streams < 1 {
+		streams = 1
+	}
+	pool = &Pool{
+		PoolOptions: options(),
+		name:        account.Name,
+		key:         key,
+		idleTimeout: idleTimeout,
+		sem:         make(chan struct{}, max),
+		streams:     make(chan struct{}, streams),
+	}
+	pools[account.ID] = pool
+	return pool
+}
+
+// ClosePool close all the idle connections of the account, used after the account changed
+func ClosePool(account *model.Account) {
+	poolsLock.Lock()
+	defer poolsLock.Unlock()
+	if pool, ok := pools[account.ID]; ok {
+		pool.close()
+		delete(pools, account.ID)
+	}
+}
+
+func (p *Pool) close() {
+	p.Lock()
+	defer p.Unlock()
+	for _, c := range p.idle {
+		p.Close(c.conn)
+	}
+	p.idle = nil
+	p.closed = true
+}
+
+func acquire(sem chan struct{}) error {
+	timer := time.NewTimer(PoolWaitTimeout)
+	defer timer.Stop()
+	select {
+	case sem <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrPoolTimeout
+	}
+}
+
+// Get get a connection for a short operation, dial if no usable idle connection
+func (p *Pool) Get() (interface{}, error) {
+	if err := acquire(p.sem); err != nil {
+		return nil, err
+	}
+	conn, err := p.get()
+	if err != nil {
+		<-p.sem
+		return nil, err
+	}
+	return conn, nil
+}
+
+// GetStream get a connection held by a stream until it's closed, must be put back with PutStream
+func (p *Pool) GetStream() (interface{}, error) {
+	if err := acquire(p.streams); err != nil {
+		return nil, err
+	}
+	conn, err := p.Get()
+	if err != nil {
+		<-p.streams
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (p *Pool) get() (interface{}, error) {
+	for {
+		p.Lock()
+		if len(p.idle) == 0 {
+			p.Unlock()
+			break
+		}
+		c := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		p.Unlock()
+		if time.Since(c.since) > p.idleTimeout {
+			p.Close(c.conn)
+			continue
+		}
+		if time.Since(c.since) > poolCheckAfter {
+			if err := p.Ping(c.conn); err != nil {
+				log.Debugf("connection of [%s] is broken: %s", p.name, err.Error())
+				p.Close(c.conn)
+				continue
+			}
+		}
+		return c.conn, nil
+	}
+	return p.Dial()
+}
+
+// Put put the connection got by Get back, the connection is closed if it's broken by the error
+func (p *Pool) Put(conn interface{}, err error) {
+	defer func() {
+		<-p.sem
+	}()
+	if err != nil && p.Broken(err) {
+		p.Close(conn)
+		return
+	}
+	p.Lock()
+	defer p.Unlock()
+	if p.closed {
+		p.Close(conn)
+		return
+	}
+	p.idle = append(p.idle, poolConn{conn: conn, since: time.Now()})
+}
+
+// PutStream put the connection got by GetStream back
+func (p *Pool) PutStream(conn interface{}, err error) {
+	p.Put(conn, err)
+	<-p.streams
+}
+
+// WithConn run the function with a connection of the pool
+func (p *Pool) WithConn(fn func(conn interface{}) error) error {
+	conn, err := p.Get()
+	if err != nil {
+		return err
+	}
+	err = fn(conn)
+	p.Put(conn, err)
+	return err
+}
+
+// clean close the connections idle too long
+func (p *Pool) clean() {
+	p.Lock()
+	defer p.Unlock()
+	idle := p.idle[:0]
+	for _, c := range p.idle {
+		if time.Since(c.since) > p.idleTimeout {
+			p.Close(c.conn)
+		} else {
+			idle = append(idle, c)
+		}
+	}
+	p.idle = idle
+}
+
+func init() {
+	go func() {
+		for range time.Tick(10 * time.Second) {
+			poolsLock.Lock()
+			for _, pool := range pools {
+				pool.clean()
+			}
+			poolsLock.Unlock()
+		}
+	}()
+}
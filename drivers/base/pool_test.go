@@ -0,0 +1,49 @@
+package base
+
+import (
+	"testing"
+
+	"github.com/Xhofe/alist/model"
+)
+
+// 流占用了可用的连接后，仍然留有一个连接给其他操作
+func TestPoolReserveConnection(t *testing.T) {
+	dialed := 0
+	account := &model.Account{ID: 1000, Name: "pool", MaxConnections: 2}
+	pool := GetPool(account, "", func() PoolOptions {
+		return PoolOptions{
+			Dial: func() (interface{}, error) {
+				dialed++
+				return dialed, nil
+			},
+			Close:  func(conn interface{}) {},
+			Ping:   func(conn interface{}) error { return nil },
+			Broken: func(err error) bool { return true },
+		}
+	})
+	defer ClosePool(account)
+	stream, err := pool.GetStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case pool.streams <- struct{}{}:
+		t.Fatal("the stream can use all the connections")
+	default:
+	}
+	if err = pool.WithConn(func(conn interface{}) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	pool.PutStream(stream, nil)
+	conn, err := pool.GetStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.PutStream(conn, nil)
+	if dialed != 2 {
+		t.Errorf("dialed %d connections, want 2", dialed)
+	}
+	if len(pool.sem) != 0 || len(pool.streams) != 0 {
+		t.Errorf("permits not released: %d, %d", len(pool.sem), len(pool.streams))
+	}
+}
@@ -0,0 +1,64 @@
+package base
+
+import (
+	"errors"
+	"io"
+)
+
+// SeekReader 按需从指定位置打开数据，用于支持Range请求
+type SeekReader struct {
+	open   func(offset int64) (io.ReadCloser, error)
+	size   int64
+	offset int64
+	reader io.ReadCloser // 当前打开的数据，位于offset处
+}
+
+func NewSeekReader(open func(offset int64) (io.ReadCloser, error), size int64) *SeekReader {
+	return &SeekReader{
+		open: open,
+		size: size,
+	}
+}
+
+func (r *SeekReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.reader == nil {
+		reader, err := r.open(r.offset)
+		if err != nil {
+			return 0, err
+		}
+		r.reader = reader
+	}
+	n, err := r.reader.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *SeekReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	// 位置变化时关闭当前的数据，下次读取时重新打开
+	if offset != r.offset {
+		_ = r.Close()
+		r.offset = offset
+	}
+	return offset, nil
+}
+
+func (r *SeekReader) Close() error {
+	if r.reader == nil {
+		return nil
+	}
+	err := r.reader.Close()
+	r.reader = nil
+	return err
+}
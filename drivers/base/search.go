@@ -0,0 +1,53 @@
+package base
+
+import (
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/utils"
+	log "github.com/sirupsen/logrus"
+	"strings"
+)
+
+// MatchKeyword check whether the name contains the keyword, ignore case
+func MatchKeyword(name string, keyword string) bool {
+	return strings.Contains(strings.ToLower(name), strings.ToLower(keyword))
+}
+
+// WalkSearch search by walking Files recursively, for drivers without their own search
+func WalkSearch(driver Driver, path string, keyword string, account *model.Account) ([]*model.File, error) {
+	path = utils.ParsePath(path)
+	res := make([]*model.File, 0)
+	dirs := []string{path}
+	for len(dirs) > 0 {
+		dir := dirs[0]
+		dirs = dirs[1:]
+		files, err := GetFiles(driver, account, dir)
+		if err != nil {
+			// 根目录出错直接返回，子目录出错则跳过
+			if dir == path {
+				return nil, err
+			}
+			log.Warnf("search [%s] in account [%s] error: %s", dir, account.Name, err.Error())
+			continue
+		}
+		for i := range files {
+			file := files[i]
+			if file.IsDir() {
+				dirs = append(dirs, utils.Join(dir, file.Name))
+			}
+			if MatchKeyword(file.Name, keyword) {
+				file.Path = dir
+				res = append(res, &file)
+			}
+		}
+	}
+	return res, nil
+}
+
+// Search use the driver's own search if it has, or fall back to WalkSearch
+func Search(driver Driver, path string, keyword string, account *model.Account) ([]*model.File, error) {
+	files, err := driver.Search(path, keyword, account)
+	if err == ErrNotSupport {
+		return WalkSearch(driver, path, keyword, account)
+	}
+	return files, err
+}
@@ -0,0 +1,348 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Xhofe/alist/conf"
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/utils"
+	log "github.com/sirupsen/logrus"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrTaskCancelled = errors.New("task cancelled")
+
+// runningTask 未结束的任务在内存中的状态
+type runningTask struct {
+	progress  Progress
+	cancel    context.CancelFunc // 运行中才有
+	cancelled bool
+	claimed   bool // 已被worker取出，只有取出的worker负责清理
+	done      chan struct{}
+}
+
+var (
+	taskQueue    = make(chan uint, 1024)
+	runningTasks = map[uint]*runningTask{}
+	tasksLock    sync.Mutex
+	taskRetries  int
+)
+
+// TaskTempDir the folder to save the uploading files before the tasks finished
+func TaskTempDir() string {
+	return filepath.Join(filepath.Dir(conf.ConfigFile), "temp")
+}
+
+// StartTasks start the workers and restore the unfinished tasks
+func StartTasks(workers int, retries int) {
+	taskRetries = retries
+	for i := 0; i < workers; i++ {
+		go taskWorker()
+	}
+	tasks, err := model.GetUnfinishedTasks()
+	if err != nil {
+		log.Errorf("failed get unfinished tasks: %s", err.Error())
+		return
+	}
+	for i := range tasks {
+		task := tasks[i]
+		task.Status = model.TaskPending
+		if err = model.SaveTask(&task); err != nil {
+			log.Errorf("failed restore task [%d]: %s", task.ID, err.Error())
+			continue
+		}
+		enqueueTask(task.ID)
+	}
+	if len(tasks) > 0 {
+		log.Infof("restored %d unfinished tasks", len(tasks))
+	}
+}
+
+// enqueueTask put the task in the queue, the task still in the queue(e.g. cancelled and retried before
+// a worker takes it) is not put again
+func enqueueTask(id uint) <-chan struct{} {
+	tasksLock.Lock()
+	if rt, ok := runningTasks[id]; ok && !rt.claimed {
+		rt.cancelled = false
+		tasksLock.Unlock()
+		return rt.done
+	}
+	rt := &runningTask{done: make(chan struct{})}
+	runningTasks[id] = rt
+	tasksLock.Unlock()
+	go func() {
+		taskQueue <- id
+	}()
+	return rt.done
+}
+
+// SubmitTask save the task and put it in the queue, the returned channel is closed when the task finished
+func SubmitTask(task *model.Task) (<-chan struct{}, error) {
+	task.Status = model.TaskPending
+	if err := model.CreateTask(task); err != nil {
+		return nil, err
+	}
+	return enqueueTask(task.ID), nil
+}
+
+// WaitTask submit the task and wait until it finished, return the error of the task
+func WaitTask(task *model.Task) error {
+	done, err := SubmitTask(task)
+	if err != nil {
+		return err
+	}
+	<-done
+	task, err = model.GetTaskById(task.ID)
+	if err != nil {
+		return err
+	}
+	switch task.Status {
+	case model.TaskSucceeded:
+		return nil
+	case model.TaskCancelled:
+		return ErrTaskCancelled
+	default:
+		return errors.New(task.Error)
+	}
+}
+
+// GetTasks get all the tasks with the progress of running tasks
+func GetTasks() ([]model.Task, error) {
+	tasks, err := model.GetTasks()
+	if err != nil {
+		return nil, err
+	}
+	tasksLock.Lock()
+	defer tasksLock.Unlock()
+	for i := range tasks {
+		if rt, ok := runningTasks[tasks[i].ID]; ok && tasks[i].Status == model.TaskRunning {
+			tasks[i].Done = atomic.LoadInt64(&rt.progress.Done)
+			tasks[i].Total = atomic.LoadInt64(&rt.progress.Total)
+		}
+	}
+	return tasks, nil
+}
+
+// GetTask get the task with the progress if it's running
+func GetTask(id uint) (*model.Task, error) {
+	task, err := model.GetTaskById(id)
+	if err != nil {
+		return nil, err
+	}
+	tasksLock.Lock()
+	defer tasksLock.Unlock()
+	if rt, ok := runningTasks[id]; ok && task.Status == model.TaskRunning {
+		task.Done = atomic.LoadInt64(&rt.progress.Done)
+		task.Total = atomic.LoadInt64(&rt.progress.Total)
+	}
+	return task, nil
+}
+
+// CancelTask cancel the pending or running task, or clean the failed task
+func CancelTask(id uint) error {
+	task, err := model.GetTaskById(id)
+	if err != nil {
+		return err
+	}
+	if task.Status == model.TaskSucceeded || task.Status == model.TaskCancelled {
+		return fmt.Errorf("task [%d] is already %s", id, task.Status)
+	}
+	tasksLock.Lock()
+	rt, ok := runningTasks[id]
+	if ok {
+		rt.cancelled = true
+		if rt.cancel != nil {
+			rt.cancel()
+		}
+	}
+	tasksLock.Unlock()
+	// 运行中的任务由worker更新状态
+	if ok && task.Status == model.TaskRunning {
+		return nil
+	}
+	task.Status = model.TaskCancelled
+	cleanTask(task)
+	return model.SaveTask(task)
+}
+
+// RetryTask run the failed or cancelled task again
+func RetryTask(id uint) error {
+	task, err := model.GetTaskById(id)
+	if err != nil {
+		return err
+	}
+	if task.Status != model.TaskFailed && task.Status != model.TaskCancelled {
+		return fmt.Errorf("task [%d] is %s", id, task.Status)
+	}
+	if task.Type == model.TaskUpload && !fileExists(task.SrcPath) {
+		return fmt.Errorf("the uploaded file of task [%d] has been removed", id)
+	}
+	task.Status = model.TaskPending
+	task.Error = ""
+	task.Retries = 0
+	task.Done = 0
+	if err = model.SaveTask(task); err != nil {
+		return err
+	}
+	enqueueTask(id)
+	return nil
+}
+
+func taskWorker() {
+	for id := range taskQueue {
+		runTask(id)
+	}
+}
+
+func runTask(id uint) {
+	tasksLock.Lock()
+	rt, ok := runningTasks[id]
+	if !ok || rt.claimed {
+		tasksLock.Unlock()
+		return
+	}
+	rt.claimed = true
+	tasksLock.Unlock()
+	defer func() {
+		tasksLock.Lock()
+		// 结束前可能已被重试，此时不能删除新的任务
+		if runningTasks[id] == rt {
+			delete(runningTasks, id)
+		}
+		tasksLock.Unlock()
+		close(rt.done)
+	}()
+	task, err := model.GetTaskById(id)
+	if err != nil || task.Status != model.TaskPending {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tasksLock.Lock()
+	rt.cancel = cancel
+	cancelled := rt.cancelled
+	tasksLock.Unlock()
+	if cancelled {
+		return
+	}
+	task.Status = model.TaskRunning
+	_ = model.SaveTask(task)
+	log.Infof("start task [%d]: %s %s%s -> %s%s", task.ID, task.Type, task.SrcAccount, task.SrcPath, task.DstAccount, task.DstPath)
+	for {
+		atomic.StoreInt64(&rt.progress.Done, 0)
+		atomic.StoreInt64(&rt.progress.Total, 0)
+		err = doTask(ctx, task, &rt.progress)
+		if err == nil || ctx.Err() != nil || task.Retries >= taskRetries {
+			break
+		}
+		task.Retries++
+		log.Warnf("task [%d] failed: %s, retry %d", task.ID, err.Error(), task.Retries)
+		_ = model.SaveTask(task)
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Duration(task.Retries) * 5 * time.Second):
+		}
+	}
+	task.Done = atomic.LoadInt64(&rt.progress.Done)
+	task.Total = atomic.LoadInt64(&rt.progress.Total)
+	switch {
+	case ctx.Err() != nil:
+		task.Status = model.TaskCancelled
+		cleanTask(task)
+	case err != nil:
+		task.Status = model.TaskFailed
+		task.Error = err.Error()
+		log.Errorf("task [%d] failed: %s", task.ID, err.Error())
+	default:
+		task.Status = model.TaskSucceeded
+		cleanTask(task)
+		log.Infof("finish task [%d]", task.ID)
+	}
+	if err = model.SaveTask(task); err != nil {
+		log.Errorf("failed save task [%d]: %s", task.ID, err.Error())
+	}
+}
+
+// cleanTask remove the temp file of the finished upload or download task
+func cleanTask(task *model.Task) {
+	switch task.Type {
+	case model.TaskUpload:
+		_ = os.Remove(task.SrcPath)
+	case model.TaskDownload:
+		_ = os.Remove(DownloadTempFile(task.ID))
+	}
+}
+
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+func getAccountDriver(name string) (*model.Account, Driver, error) {
+	account, ok := model.GetAccount(name)
+	if !ok {
+		return nil, nil, fmt.Errorf("no [%s] account", name)
+	}
+	driver, ok := GetDriver(account.Type)
+	if !ok {
+		return nil, nil, fmt.Errorf("no [%s] driver", account.Type)
+	}
+	return &account, driver, nil
+}
+
+func doTask(ctx context.Context, task *model.Task, progress *Progress) error {
+	dstAccount, dstDriver, err := getAccountDriver(task.DstAccount)
+	if err != nil {
+		return err
+	}
+	switch task.Type {
+	case model.TaskDelete:
+		return DoDelete(dstDriver, dstAccount, task.DstPath)
+	case model.TaskUpload:
+		return uploadTempFile(ctx, dstDriver, dstAccount, task, progress)
+	case model.TaskDownload:
+		return Download(ctx, task, dstDriver, dstAccount, progress)
+	case model.TaskCopy, model.TaskMove:
+		srcAccount, srcDriver, err := getAccountDriver(task.SrcAccount)
+		if err != nil {
+			return err
+		}
+		err = Transfer(ctx, srcDriver, srcAccount, task.SrcPath, dstDriver, dstAccount, task.DstPath, progress)
+		if err != nil || task.Type == model.TaskCopy {
+			return err
+		}
+		return DoDelete(srcDriver, srcAccount, task.SrcPath)
+	}
+	return fmt.Errorf("unknown task type: %s", task.Type)
+}
+
+func uploadTempFile(ctx context.Context, driver Driver, account *model.Account, task *model.Task, progress *Progress) error {
+	file, err := os.Open(task.SrcPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	atomic.StoreInt64(&progress.Total, stat.Size())
+	fileStream := model.FileStream{
+		File:       &progressReader{ReadCloser: file, ctx: ctx, done: &progress.Done},
+		Size:       uint64(stat.Size()),
+		ParentPath: utils.Dir(task.DstPath),
+		Name:       utils.Base(task.DstPath),
+		MIMEType:   mime.TypeByExtension(path.Ext(task.DstPath)),
+		Ctx:        ctx,
+	}
+	return DoUpload(driver, account, &fileStream)
+}
@@ -0,0 +1,101 @@
+package base
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/utils/testdb"
+)
+
+var startWorkers sync.Once
+
+func initTaskTest(t *testing.T) {
+	testdb.Init(t, &model.Task{})
+}
+
+func waitDone(t *testing.T, done <-chan struct{}) {
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("task not finished")
+	}
+}
+
+func taskStatus(t *testing.T, id uint) string {
+	task, err := model.GetTaskById(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return task.Status
+}
+
+// 等待中的任务取消后重试，不能被重复放入队列
+func TestCancelThenRetryPendingTask(t *testing.T) {
+	initTaskTest(t)
+	// 账号不存在，任务会直接失败
+	task := model.Task{Type: model.TaskDelete, DstAccount: "missing", DstPath: "/a"}
+	done, err := SubmitTask(&task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = CancelTask(task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err = RetryTask(task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err = CancelTask(task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err = RetryTask(task.ID); err != nil {
+		t.Fatal(err)
+	}
+	startWorkers.Do(func() {
+		for i := 0; i < 4; i++ {
+			go taskWorker()
+		}
+	})
+	waitDone(t, done)
+	if status := taskStatus(t, task.ID); status != model.TaskFailed {
+		t.Errorf("status = %s, want %s", status, model.TaskFailed)
+	}
+	time.Sleep(100 * time.Millisecond)
+	tasksLock.Lock()
+	defer tasksLock.Unlock()
+	if len(runningTasks) != 0 || len(taskQueue) != 0 {
+		t.Errorf("%d tasks left, %d in queue", len(runningTasks), len(taskQueue))
+	}
+}
+
+// 已经结束的任务重试时重新放入队列
+func TestRetryFinishedTask(t *testing.T) {
+	initTaskTest(t)
+	startWorkers.Do(func() {
+		for i := 0; i < 4; i++ {
+			go taskWorker()
+		}
+	})
+	task := model.Task{Type: model.TaskDelete, DstAccount: "missing", DstPath: "/a"}
+	done, err := SubmitTask(&task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitDone(t, done)
+	if status := taskStatus(t, task.ID); status != model.TaskFailed {
+		t.Fatalf("status = %s, want %s", status, model.TaskFailed)
+	}
+	if err = RetryTask(task.ID); err != nil {
+		t.Fatal(err)
+	}
+	tasksLock.Lock()
+	rt, ok := runningTasks[task.ID]
+	tasksLock.Unlock()
+	if ok {
+		waitDone(t, rt.done)
+	}
+	if status := taskStatus(t, task.ID); status != model.TaskFailed {
+		t.Errorf("status = %s, want %s", status, model.TaskFailed)
+	}
+}
@@ -0,0 +1,143 @@
+package base
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/utils"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"sync/atomic"
+)
+
+// Progress 传输进度,字段使用原子操作读写
+type Progress struct {
+	Done  int64 // 已传输的字节数
+	Total int64 // 已知的总字节数，随遍历增长
+}
+
+// Transfer 跨账号复制，从源账号读取数据后上传到目标账号，遇到文件夹时递归
+func Transfer(ctx context.Context, srcDriver Driver, srcAccount *model.Account, src string,
+	dstDriver Driver, dstAccount *model.Account, dst string, progress *Progress) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	file, err := srcDriver.File(src, srcAccount)
+	if err != nil {
+		return err
+	}
+	if !file.IsDir() {
+		return transferFile(ctx, srcDriver, srcAccount, src, dstDriver, dstAccount, dst, file, progress)
+	}
+	if err = DoMakeDir(dstDriver, dstAccount, dst); err != nil {
+		return err
+	}
+	files, err := srcDriver.Files(src, srcAccount)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		err = Transfer(ctx, srcDriver, srcAccount, utils.Join(src, f.Name),
+			dstDriver, dstAccount, utils.Join(dst, f.Name), progress)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func transferFile(ctx context.Context, srcDriver Driver, srcAccount *model.Account, src string,
+	dstDriver Driver, dstAccount *model.Account, dst string, file *model.File, progress *Progress) error {
+	log.Debugf("transfer file: %s -> %s", src, dst)
+	reader, size, err := Open(ctx, srcDriver, srcAccount, src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	if size < 0 {
+		size = file.Size
+	}
+	atomic.AddInt64(&progress.Total, size)
+	fileStream := model.FileStream{
+		File:       &progressReader{ReadCloser: reader, ctx: ctx, done: &progress.Done},
+		Size:       uint64(size),
+		ParentPath: utils.Dir(dst),
+		Name:       utils.Base(dst),
+		MIMEType:   mime.TypeByExtension(path.Ext(dst)),
+		Ctx:        ctx,
+	}
+	return DoUpload(dstDriver, dstAccount, &fileStream)
+}
+
+// progressReader 记录读取的字节数，任务取消后读取返回错误
+type progressReader struct {
+	io.ReadCloser
+	ctx  context.Context
+	done *int64
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := r.ReadCloser.Read(p)
+	atomic.AddInt64(r.done, int64(n))
+	return n, err
+}
+
+// Open get the data of the file, the size is -1 if unknown
+func Open(ctx context.Context, driver Driver, account *model.Account, path string) (io.ReadCloser, int64, error) {
+	link, err := GetLink(driver, account, path)
+	if err != nil {
+		return nil, 0, err
+	}
+	// 本机读取数据
+	if link.Reader != nil {
+		reader, err := link.Reader(0)
+		return reader, -1, err
+	}
+	if link.Data != nil {
+		return ioutil.NopCloser(bytes.NewReader(link.Data)), int64(len(link.Data)), nil
+	}
+	// 本机文件直接打开
+	if account.Type == "Native" {
+		f, err := os.Open(link.Url)
+		if err != nil {
+			return nil, 0, err
+		}
+		stat, err := f.Stat()
+		if err != nil {
+			_ = f.Close()
+			return nil, 0, err
+		}
+		return f, stat.Size(), nil
+	}
+	if link.Url == "" {
+		return nil, 0, errors.New("empty link")
+	}
+	res, err := RequestLink(ctx, link, 0)
+	// 链接提前过期时刷新后重试一次
+	if err == nil && IsLinkExpired(res.StatusCode) {
+		_ = res.Body.Close()
+		if link, err = RefreshLink(driver, account, path); err == nil {
+			res, err = RequestLink(ctx, link, 0)
+		}
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if res.StatusCode != http.StatusOK {
+		_ = res.Body.Close()
+		return nil, 0, fmt.Errorf("get data of [%s] error: %s", path, res.Status)
+	}
+	return res.Body, res.ContentLength, nil
+}
@@ -2,6 +2,8 @@ package base
 
 import (
 	"errors"
+	"io"
+	"time"
 )
 
 var (
@@ -10,6 +12,7 @@ var (
 	ErrNotImplement = errors.New("not implement")
 	ErrNotSupport   = errors.New("not support")
 	ErrNotFolder    = errors.New("not a folder")
+	ErrIndexing     = errors.New("account is indexing")
 )
 
 const (
@@ -43,4 +46,14 @@ type Link struct {
 	Url string `json:"url"`
 	Headers []Header `json:"headers"`
 	Data []byte
+	// 只能本机读取的数据，从offset处开始读取
+	Reader func(offset int64) (io.ReadCloser, error) `json:"-"`
+	// 链接的过期时间，设置后链接会被缓存到过期前不久
+	Expiration *time.Time `json:"expiration"`
+}
+
+// ExpireIn get the expiration of the link valid for the duration from now
+func ExpireIn(d time.Duration) *time.Time {
+	t := time.Now().Add(d)
+	return &t
 }
\ No newline at end of file
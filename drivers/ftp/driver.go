@@ -8,8 +8,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jlaffaye/ftp"
 	log "github.com/sirupsen/logrus"
-	"io/ioutil"
+	"io"
 	"path/filepath"
+	"strings"
 )
 
 type FTP struct{}
@@ -48,6 +49,41 @@ func (driver FTP) Items() []base.Item {
 			Type:     base.TypeString,
 			Required: false,
 		},
+		{
+			Name:        "tls_mode",
+			Label:       "tls mode",
+			Type:        base.TypeSelect,
+			Values:      "none,explicit,implicit",
+			Required:    false,
+			Description: "explicit: AUTH TLS after connected, implicit: TLS from the beginning, usually port 990",
+		},
+		{
+			Name:     "tls_insecure",
+			Label:    "skip certificate verification",
+			Type:     base.TypeBool,
+			Required: false,
+		},
+		{
+			Name:        "ca_file",
+			Label:       "ca certificate file",
+			Type:        base.TypeString,
+			Required:    false,
+			Description: "path of the PEM CA certificate file to verify the server, empty to use the system ones",
+		},
+		{
+			Name:        "max_connections",
+			Label:       "max connections",
+			Type:        base.TypeNumber,
+			Required:    false,
+			Description: "max connections in the connection pool, default 5",
+		},
+		{
+			Name:        "idle_timeout",
+			Label:       "idle timeout",
+			Type:        base.TypeNumber,
+			Required:    false,
+			Description: "seconds the idle connections are kept in the pool, default 60",
+		},
 		{
 			Name:     "order_by",
 			Label:    "order_by",
@@ -69,12 +105,14 @@ func (driver FTP) Save(account *model.Account, old *model.Account) error {
 	if account.RootFolder == "" {
 		account.RootFolder = "/"
 	}
-	conn, err := driver.Login(account)
+	base.ClosePool(account)
+	err := driver.withConn(account, func(conn *ftp.ServerConn) error {
+		return conn.NoOp()
+	})
 	if err != nil {
 		account.Status = err.Error()
 	} else {
 		account.Status = "work"
-		_ = conn.Quit()
 	}
 	_ = model.SaveAccount(account)
 	return err
@@ -109,18 +147,17 @@ func (driver FTP) File(path string, account *model.Account) (*model.File, error)
 func (driver FTP) Files(path string, account *model.Account) ([]model.File, error) {
 	log.Debugf("files: %s", path)
 	path = utils.ParsePath(path)
-	cache, err := base.GetCache(path, account)
+	var cache []model.File
+	err := base.GetCache(path, account, &cache)
 	if err == nil {
-		files, _ := cache.([]model.File)
-		return files, nil
+		return cache, nil
 	}
 	realPath := utils.Join(account.RootFolder, path)
-	conn, err := driver.Login(account)
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = conn.Quit() }()
-	entries, err := conn.List(realPath)
+	var entries []*ftp.Entry
+	err = driver.withConn(account, func(conn *ftp.ServerConn) error {
+		entries, err = conn.List(realPath)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -140,31 +177,24 @@ func (driver FTP) Files(path string, account *model.Account) ([]model.File, erro
 		}
 		res = append(res, f)
 	}
-	if len(res) > 0 {
-		_ = base.SetCache(path, res, account)
-	}
+	_ = base.SetCache(path, res, account)
 	return res, nil
 }
 
 func (driver FTP) Link(path string, account *model.Account) (*base.Link, error) {
 	path = utils.ParsePath(path)
 	realPath := utils.Join(account.RootFolder, path)
-	conn, err := driver.Login(account)
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = conn.Quit() }()
-	resp, err := conn.Retr(realPath)
+	file, err := driver.File(path, account)
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Close() }()
-	data, err := ioutil.ReadAll(resp)
-	if err != nil {
-		return nil, err
+	if file.IsDir() {
+		return nil, base.ErrNotFile
 	}
 	return &base.Link{
-		Data: data,
+		Reader: func(offset int64) (io.ReadCloser, error) {
+			return driver.Retr(realPath, offset, account)
+		},
 	}, nil
 }
 
@@ -194,15 +224,56 @@ func (driver FTP) Preview(path string, account *model.Account) (interface{}, err
 	return nil, base.ErrNotSupport
 }
 
-func (driver FTP) MakeDir(path string, account *model.Account) error {
+func (driver FTP) Search(path string, keyword string, account *model.Account) ([]*model.File, error) {
 	path = utils.ParsePath(path)
 	realPath := utils.Join(account.RootFolder, path)
-	conn, err := driver.Login(account)
+	res := make([]*model.File, 0)
+	err := driver.withConn(account, func(conn *ftp.ServerConn) error {
+		return driver.walkSearch(conn, realPath, keyword, account, &res)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer func() { _ = conn.Quit() }()
-	err = conn.MakeDir(realPath)
+	return res, nil
+}
+
+func (driver FTP) walkSearch(conn *ftp.ServerConn, realPath string, keyword string, account *model.Account, res *[]*model.File) error {
+	walker := conn.Walk(realPath)
+	for walker.Next() {
+		entry := walker.Stat()
+		if !base.MatchKeyword(entry.Name, keyword) {
+			continue
+		}
+		dir := strings.TrimPrefix(utils.Dir(walker.Path()), strings.TrimRight(account.RootFolder, "/"))
+		f := &model.File{
+			Name:      entry.Name,
+			Size:      int64(entry.Size),
+			UpdatedAt: &entry.Time,
+			Driver:    driver.Config().Name,
+			Path:      utils.ParsePath(dir),
+		}
+		if entry.Type == ftp.EntryTypeFolder {
+			f.Type = conf.FOLDER
+		} else {
+			f.Type = utils.GetFileType(filepath.Ext(entry.Name))
+		}
+		*res = append(*res, f)
+	}
+	if err := walker.Err(); err != nil {
+		if len(*res) == 0 {
+			return err
+		}
+		log.Warnf("search walk [%s] error: %s", walker.Path(), err.Error())
+	}
+	return nil
+}
+
+func (driver FTP) MakeDir(path string, account *model.Account) error {
+	path = utils.ParsePath(path)
+	realPath := utils.Join(account.RootFolder, path)
+	err := driver.withConn(account, func(conn *ftp.ServerConn) error {
+		return conn.MakeDir(realPath)
+	})
 	if err == nil {
 		_ = base.DeleteCache(utils.Dir(path), account)
 	}
@@ -215,12 +286,9 @@ func (driver FTP) Move(src string, dst string, account *model.Account) error {
 	//}
 	realSrc := utils.Join(account.RootFolder, src)
 	realDst := utils.Join(account.RootFolder, dst)
-	conn, err := driver.Login(account)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = conn.Quit() }()
-	err = conn.Rename(realSrc, realDst)
+	err := driver.withConn(account, func(conn *ftp.ServerConn) error {
+		return conn.Rename(realSrc, realDst)
+	})
 	if err != nil {
 		_ = base.DeleteCache(utils.Dir(src), account)
 		_ = base.DeleteCache(utils.Dir(dst), account)
@@ -235,12 +303,9 @@ func (driver FTP) Copy(src string, dst string, account *model.Account) error {
 func (driver FTP) Delete(path string, account *model.Account) error {
 	path = utils.ParsePath(path)
 	realPath := utils.Join(account.RootFolder, path)
-	conn, err := driver.Login(account)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = conn.Quit() }()
-	err = conn.Delete(realPath)
+	err := driver.withConn(account, func(conn *ftp.ServerConn) error {
+		return conn.Delete(realPath)
+	})
 	if err == nil {
 		_ = base.DeleteCache(utils.Dir(path), account)
 	}
@@ -249,12 +314,9 @@ func (driver FTP) Delete(path string, account *model.Account) error {
 
 func (driver FTP) Upload(file *model.FileStream, account *model.Account) error {
 	realPath := utils.Join(account.RootFolder, file.ParentPath, file.Name)
-	conn, err := driver.Login(account)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = conn.Quit() }()
-	err = conn.Stor(realPath, file)
+	err := driver.withConn(account, func(conn *ftp.ServerConn) error {
+		return conn.Stor(realPath, file)
+	})
 	if err == nil {
 		_ = base.DeleteCache(utils.Dir(file.ParentPath), account)
 	}
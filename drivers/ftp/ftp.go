@@ -1,18 +1,63 @@
 package ftp
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"github.com/Xhofe/alist/drivers/base"
 	"github.com/Xhofe/alist/model"
 	"github.com/jlaffaye/ftp"
+	"io"
+	"io/ioutil"
+	"net"
+	"time"
 )
 
+// tlsConfig get the TLS config of the account
+func tlsConfig(account *model.Account) (*tls.Config, error) {
+	host, _, err := net.SplitHostPort(account.SiteUrl)
+	if err != nil {
+		host = account.SiteUrl
+	}
+	config := &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: account.TlsInsecure,
+	}
+	if account.CaFile != "" {
+		pem, err := ioutil.ReadFile(account.CaFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate in [%s]", account.CaFile)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
 func (driver FTP) Login(account *model.Account) (*ftp.ServerConn, error) {
-	conn, err := ftp.Connect(account.SiteUrl)
+	options := []ftp.DialOption{ftp.DialWithTimeout(10 * time.Second)}
+	switch account.TlsMode {
+	case "explicit", "implicit":
+		config, err := tlsConfig(account)
+		if err != nil {
+			return nil, err
+		}
+		if account.TlsMode == "explicit" {
+			options = append(options, ftp.DialWithExplicitTLS(config))
+		} else {
+			options = append(options, ftp.DialWithTLS(config))
+		}
+	}
+	conn, err := ftp.Dial(account.SiteUrl, options...)
 	if err != nil {
 		return nil, err
 	}
 	err = conn.Login(account.Username, account.Password)
 	if err != nil {
+		_ = conn.Quit()
 		return nil, err
 	}
 	return conn, nil
@@ -20,4 +65,33 @@ func (driver FTP) Login(account *model.Account) (*ftp.ServerConn, error) {
 
 func init() {
 	base.RegisterDriver(&FTP{})
-}
\ No newline at end of file
+}
+
+// ftpReader 读取完成后将连接放回连接池
+type ftpReader struct {
+	*ftp.Response
+	conn *ftp.ServerConn
+	pool *base.Pool
+}
+
+func (r *ftpReader) Close() error {
+	err := r.Response.Close()
+	r.pool.PutStream(r.conn, err)
+	return err
+}
+
+// Retr read the file from the offset using REST
+func (driver FTP) Retr(realPath string, offset int64, account *model.Account) (io.ReadCloser, error) {
+	pool := getPool(driver, account)
+	c, err := pool.GetStream()
+	if err != nil {
+		return nil, err
+	}
+	conn := c.(*ftp.ServerConn)
+	resp, err := conn.RetrFrom(realPath, uint64(offset))
+	if err != nil {
+		pool.PutStream(conn, err)
+		return nil, err
+	}
+	return &ftpReader{Response: resp, conn: conn, pool: pool}, nil
+}
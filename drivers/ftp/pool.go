@@ -0,0 +1,43 @@
+package ftp
+
+import (
+	"fmt"
+	"github.com/Xhofe/alist/drivers/base"
+	"github.com/Xhofe/alist/model"
+	"github.com/jlaffaye/ftp"
+	"net/textproto"
+)
+
+func poolKey(account *model.Account) string {
+	return fmt.Sprintf("%s\n%s\n%s\n%s\n%v\n%s", account.SiteUrl, account.Username, account.Password,
+		account.TlsMode, account.TlsInsecure, account.CaFile)
+}
+
+func getPool(driver FTP, account *model.Account) *base.Pool {
+	return base.GetPool(account, poolKey(account), func() base.PoolOptions {
+		acc := *account
+		return base.PoolOptions{
+			Dial: func() (interface{}, error) {
+				return driver.Login(&acc)
+			},
+			Close: func(conn interface{}) {
+				_ = conn.(*ftp.ServerConn).Quit()
+			},
+			Ping: func(conn interface{}) error {
+				return conn.(*ftp.ServerConn).NoOp()
+			},
+			// FTP的错误回复不影响连接
+			Broken: func(err error) bool {
+				_, ok := err.(*textproto.Error)
+				return !ok
+			},
+		}
+	})
+}
+
+// withConn run the function with a connection of the pool
+func (driver FTP) withConn(account *model.Account, fn func(conn *ftp.ServerConn) error) error {
+	return getPool(driver, account).WithConn(func(conn interface{}) error {
+		return fn(conn.(*ftp.ServerConn))
+	})
+}
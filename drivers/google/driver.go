@@ -93,10 +93,8 @@ func (driver GoogleDrive) File(path string, account *model.Account) (*model.File
 func (driver GoogleDrive) Files(path string, account *model.Account) ([]model.File, error) {
 	path = utils.ParsePath(path)
 	var rawFiles []GoogleFile
-	cache, err := base.GetCache(path, account)
-	if err == nil {
-		rawFiles, _ = cache.([]GoogleFile)
-	} else {
+	err := base.GetCache(path, account, &rawFiles)
+	if err != nil {
 		file, err := driver.File(path, account)
 		if err != nil {
 			return nil, err
@@ -105,9 +103,7 @@ func (driver GoogleDrive) Files(path string, account *model.Account) ([]model.Fi
 		if err != nil {
 			return nil, err
 		}
-		if len(rawFiles) > 0 {
-			_ = base.SetCache(path, rawFiles, account)
-		}
+		_ = base.SetCache(path, rawFiles, account)
 	}
 	files := make([]model.File, 0)
 	for _, file := range rawFiles {
@@ -178,6 +174,10 @@ func (driver GoogleDrive) Preview(path string, account *model.Account) (interfac
 	return nil, base.ErrNotSupport
 }
 
+func (driver GoogleDrive) Search(path string, keyword string, account *model.Account) ([]*model.File, error) {
+	return nil, base.ErrNotSupport
+}
+
 func (driver GoogleDrive) MakeDir(path string, account *model.Account) error {
 	return base.ErrNotImplement
 }
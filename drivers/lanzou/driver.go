@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 	"path/filepath"
+	"time"
 )
 
 type Lanzou struct{}
@@ -91,10 +92,8 @@ func (driver Lanzou) File(path string, account *model.Account) (*model.File, err
 func (driver Lanzou) Files(path string, account *model.Account) ([]model.File, error) {
 	path = utils.ParsePath(path)
 	var rawFiles []LanZouFile
-	cache, err := base.GetCache(path, account)
-	if err == nil {
-		rawFiles, _ = cache.([]LanZouFile)
-	} else {
+	err := base.GetCache(path, account, &rawFiles)
+	if err != nil {
 		file, err := driver.File(path, account)
 		if err != nil {
 			return nil, err
@@ -103,9 +102,7 @@ func (driver Lanzou) Files(path string, account *model.Account) ([]model.File, e
 		if err != nil {
 			return nil, err
 		}
-		if len(rawFiles) > 0 {
-			_ = base.SetCache(path, rawFiles, account)
-		}
+		_ = base.SetCache(path, rawFiles, account)
 	}
 	files := make([]model.File, 0)
 	for _, file := range rawFiles {
@@ -133,6 +130,8 @@ func (driver Lanzou) Link(path string, account *model.Account) (*base.Link, erro
 	}
 	link := base.Link{
 		Url: url,
+		// 解析网页得到的链接，有效期未知，取保守值
+		Expiration: base.ExpireIn(5 * time.Minute),
 	}
 	return &link, nil
 }
@@ -167,6 +166,10 @@ func (driver Lanzou) Preview(path string, account *model.Account) (interface{},
 	return nil, base.ErrNotSupport
 }
 
+func (driver Lanzou) Search(path string, keyword string, account *model.Account) ([]*model.File, error) {
+	return nil, base.ErrNotSupport
+}
+
 func (driver *Lanzou) MakeDir(path string, account *model.Account) error {
 	return base.ErrNotImplement
 }
@@ -164,6 +164,55 @@ func (driver Native) Preview(path string, account *model.Account) (interface{},
 	return nil, base.ErrNotSupport
 }
 
+func (driver Native) Search(path string, keyword string, account *model.Account) ([]*model.File, error) {
+	fullPath := filepath.Join(account.RootFolder, path)
+	if !utils.Exists(fullPath) {
+		return nil, base.ErrPathNotFound
+	}
+	res := make([]*model.File, 0)
+	err := filepath.Walk(fullPath, func(p string, f os.FileInfo, err error) error {
+		if err != nil {
+			log.Warnf("search walk [%s] error: %s", p, err.Error())
+			return nil
+		}
+		if p == fullPath {
+			return nil
+		}
+		if strings.HasPrefix(f.Name(), ".") {
+			if f.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !base.MatchKeyword(f.Name(), keyword) {
+			return nil
+		}
+		rel, err := filepath.Rel(account.RootFolder, filepath.Dir(p))
+		if err != nil {
+			return err
+		}
+		time := f.ModTime()
+		file := &model.File{
+			Name:      f.Name(),
+			Size:      f.Size(),
+			UpdatedAt: &time,
+			Driver:    driver.Config().Name,
+			Path:      utils.ParsePath(filepath.ToSlash(utils.Join("/", rel))),
+		}
+		if f.IsDir() {
+			file.Type = conf.FOLDER
+		} else {
+			file.Type = utils.GetFileType(filepath.Ext(f.Name()))
+		}
+		res = append(res, file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
 func (driver Native) MakeDir(path string, account *model.Account) error {
 	fullPath := filepath.Join(account.RootFolder, path)
 	err := os.MkdirAll(fullPath, 0700)
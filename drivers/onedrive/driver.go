@@ -105,16 +105,15 @@ func (driver Onedrive) Save(account *model.Account, old *model.Account) error {
 	if err != nil {
 		return err
 	}
+	id := account.ID
 	cronId, err := conf.Cron.AddFunc("@every 1h", func() {
-		name := account.Name
-		log.Debugf("onedrive account name: %s", name)
-		newAccount, ok := model.GetAccount(name)
-		log.Debugf("onedrive account: %+v", newAccount)
-		if !ok {
-			return
+		log.Debugf("refresh the token of onedrive account [%d]", id)
+		err := model.RefreshAccountToken(id, func(account *model.Account) error {
+			return driver.RefreshToken(account)
+		})
+		if err != nil {
+			log.Warnf("failed to refresh the token of onedrive account [%d]: %s", id, err.Error())
 		}
-		err = driver.RefreshToken(&newAccount)
-		_ = model.SaveAccount(&newAccount)
 	})
 	if err != nil {
 		return err
@@ -154,10 +153,10 @@ func (driver Onedrive) File(path string, account *model.Account) (*model.File, e
 
 func (driver Onedrive) Files(path string, account *model.Account) ([]model.File, error) {
 	path = utils.ParsePath(path)
-	cache, err := base.GetCache(path, account)
+	var cache []model.File
+	err := base.GetCache(path, account, &cache)
 	if err == nil {
-		files, _ := cache.([]model.File)
-		return files, nil
+		return cache, nil
 	}
 	rawFiles, err := driver.GetFiles(account, path)
 	if err != nil {
@@ -167,9 +166,7 @@ func (driver Onedrive) Files(path string, account *model.Account) ([]model.File,
 	for _, file := range rawFiles {
 		files = append(files, *driver.FormatFile(&file))
 	}
-	if len(files) > 0 {
-		_ = base.SetCache(path, files, account)
-	}
+	_ = base.SetCache(path, files, account)
 	return files, nil
 }
 
@@ -212,6 +209,10 @@ func (driver Onedrive) Preview(path string, account *model.Account) (interface{}
 	return nil, base.ErrNotSupport
 }
 
+func (driver Onedrive) Search(path string, keyword string, account *model.Account) ([]*model.File, error) {
+	return nil, base.ErrNotSupport
+}
+
 func (driver Onedrive) MakeDir(path string, account *model.Account) error {
 	return base.ErrNotImplement
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 	"path/filepath"
+	"time"
 )
 
 type PikPak struct{}
@@ -76,10 +77,8 @@ func (driver PikPak) File(path string, account *model.Account) (*model.File, err
 func (driver PikPak) Files(path string, account *model.Account) ([]model.File, error) {
 	path = utils.ParsePath(path)
 	var files []model.File
-	cache, err := base.GetCache(path, account)
-	if err == nil {
-		files, _ = cache.([]model.File)
-	} else {
+	err := base.GetCache(path, account, &files)
+	if err != nil {
 		file, err := driver.File(path, account)
 		if err != nil {
 			return nil, err
@@ -92,9 +91,7 @@ func (driver PikPak) Files(path string, account *model.Account) ([]model.File, e
 		for _, file := range rawFiles {
 			files = append(files, *driver.FormatFile(&file))
 		}
-		if len(files) > 0 {
-			_ = base.SetCache(path, files, account)
-		}
+		_ = base.SetCache(path, files, account)
 	}
 	return files, nil
 }
@@ -110,9 +107,13 @@ func (driver PikPak) Link(path string, account *model.Account) (*base.Link, erro
 	if err != nil {
 		return nil, err
 	}
-	return &base.Link{
+	link := base.Link{
 		Url: resp.WebContentLink,
-	}, nil
+	}
+	if expiration, err := time.Parse(time.RFC3339, resp.Links.ApplicationOctetStream.Expire); err == nil {
+		link.Expiration = &expiration
+	}
+	return &link, nil
 }
 
 func (driver PikPak) Path(path string, account *model.Account) (*model.File, []model.File, error) {
@@ -145,6 +146,10 @@ func (driver PikPak) Preview(path string, account *model.Account) (interface{},
 	return nil, base.ErrNotSupport
 }
 
+func (driver PikPak) Search(path string, keyword string, account *model.Account) ([]*model.File, error) {
+	return nil, base.ErrNotSupport
+}
+
 func (driver PikPak) MakeDir(path string, account *model.Account) error {
 	path = utils.ParsePath(path)
 	dir, name := filepath.Split(path)
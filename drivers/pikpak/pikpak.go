@@ -121,6 +121,12 @@ type File struct {
 	Size           string     `json:"size"`
 	ThumbnailLink  string     `json:"thumbnail_link"`
 	WebContentLink string     `json:"web_content_link"`
+	Links          struct {
+		ApplicationOctetStream struct {
+			Url    string `json:"url"`
+			Expire string `json:"expire"`
+		} `json:"application/octet-stream"`
+	} `json:"links"`
 }
 
 func (driver PikPak) FormatFile(file *File) *model.File {
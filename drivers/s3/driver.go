@@ -0,0 +1,310 @@
+package s3
+
+import (
+	"github.com/Xhofe/alist/conf"
+	"github.com/Xhofe/alist/drivers/base"
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/utils"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"path/filepath"
+	"strings"
+)
+
+type S3 struct{}
+
+func (driver S3) Config() base.DriverConfig {
+	return base.DriverConfig{
+		Name: "S3",
+	}
+}
+
+func (driver S3) Items() []base.Item {
+	return []base.Item{
+		{
+			Name:        "endpoint",
+			Label:       "endpoint",
+			Type:        base.TypeString,
+			Required:    false,
+			Description: "such as http://127.0.0.1:9000 for MinIO, empty for AWS S3",
+		},
+		{
+			Name:     "region",
+			Label:    "region",
+			Type:     base.TypeString,
+			Required: true,
+		},
+		{
+			Name:     "bucket",
+			Label:    "bucket",
+			Type:     base.TypeString,
+			Required: true,
+		},
+		{
+			Name:     "access_key",
+			Label:    "access key",
+			Type:     base.TypeString,
+			Required: true,
+		},
+		{
+			Name:     "access_secret",
+			Label:    "access secret",
+			Type:     base.TypeString,
+			Required: true,
+		},
+		{
+			Name:        "path_style",
+			Label:       "path style",
+			Type:        base.TypeBool,
+			Required:    false,
+			Description: "use path style url such as http://endpoint/bucket/key, usually for MinIO",
+		},
+		{
+			Name:        "link_expire",
+			Label:       "link expire",
+			Type:        base.TypeNumber,
+			Required:    false,
+			Description: "minutes the presigned links are valid, default 240, at most 10080",
+		},
+		{
+			Name:     "root_folder",
+			Label:    "root folder path",
+			Type:     base.TypeString,
+			Required: false,
+		},
+		{
+			Name:     "order_by",
+			Label:    "order_by",
+			Type:     base.TypeSelect,
+			Values:   "name,size,updated_at",
+			Required: false,
+		},
+		{
+			Name:     "order_direction",
+			Label:    "order_direction",
+			Type:     base.TypeSelect,
+			Values:   "ASC,DESC",
+			Required: false,
+		},
+	}
+}
+
+func (driver S3) Save(account *model.Account, old *model.Account) error {
+	if account.RootFolder == "" {
+		account.RootFolder = "/"
+	}
+	client, err := driver.client(account)
+	if err == nil {
+		_, err = client.HeadBucket(&s3.HeadBucketInput{
+			Bucket: aws.String(account.Bucket),
+		})
+	}
+	if err != nil {
+		account.Status = err.Error()
+	} else {
+		account.Status = "work"
+	}
+	_ = model.SaveAccount(account)
+	return err
+}
+
+func (driver S3) File(path string, account *model.Account) (*model.File, error) {
+	path = utils.ParsePath(path)
+	if path == "/" {
+		return &model.File{
+			Id:        account.RootFolder,
+			Name:      account.Name,
+			Size:      0,
+			Type:      conf.FOLDER,
+			Driver:    driver.Config().Name,
+			UpdatedAt: account.UpdatedAt,
+		}, nil
+	}
+	dir, name := filepath.Split(path)
+	files, err := driver.Files(dir, account)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if file.Name == name {
+			return &file, nil
+		}
+	}
+	return nil, base.ErrPathNotFound
+}
+
+func (driver S3) Files(path string, account *model.Account) ([]model.File, error) {
+	path = utils.ParsePath(path)
+	var cache []model.File
+	err := base.GetCache(path, account, &cache)
+	if err == nil {
+		return cache, nil
+	}
+	client, err := driver.client(account)
+	if err != nil {
+		return nil, err
+	}
+	prefix := getKey(path, account, true)
+	input := &s3.ListObjectsV2Input{
+		Bucket:    aws.String(account.Bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	}
+	res := make([]model.File, 0)
+	folders := make(map[string]bool)
+	addFolder := func(name string) {
+		if folders[name] {
+			return
+		}
+		folders[name] = true
+		res = append(res, model.File{
+			Name:      name,
+			Type:      conf.FOLDER,
+			Driver:    driver.Config().Name,
+			UpdatedAt: account.UpdatedAt,
+		})
+	}
+	for {
+		output, err := client.ListObjectsV2(input)
+		if err != nil {
+			return nil, err
+		}
+		// 以分隔符结尾的前缀作为文件夹
+		for _, commonPrefix := range output.CommonPrefixes {
+			addFolder(strings.TrimSuffix(strings.TrimPrefix(*commonPrefix.Prefix, prefix), "/"))
+		}
+		for _, object := range output.Contents {
+			name := strings.TrimPrefix(*object.Key, prefix)
+			// 当前文件夹的占位对象
+			if name == "" {
+				continue
+			}
+			// 部分兼容实现会把子文件夹的占位对象放在Contents中
+			if strings.HasSuffix(name, "/") {
+				addFolder(strings.TrimSuffix(name, "/"))
+				continue
+			}
+			res = append(res, model.File{
+				Name:      name,
+				Size:      aws.Int64Value(object.Size),
+				Type:      utils.GetFileType(filepath.Ext(name)),
+				Driver:    driver.Config().Name,
+				UpdatedAt: object.LastModified,
+			})
+		}
+		if !aws.BoolValue(output.IsTruncated) {
+			break
+		}
+		input.ContinuationToken = output.NextContinuationToken
+	}
+	_ = base.SetCache(path, res, account)
+	return res, nil
+}
+
+func (driver S3) Link(path string, account *model.Account) (*base.Link, error) {
+	client, err := driver.client(account)
+	if err != nil {
+		return nil, err
+	}
+	req, _ := client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(account.Bucket),
+		Key:    aws.String(getKey(path, account, false)),
+	})
+	expire := linkExpire(account)
+	u, err := req.Presign(expire)
+	if err != nil {
+		return nil, err
+	}
+	return &base.Link{
+		Url:        u,
+		Expiration: base.ExpireIn(expire),
+	}, nil
+}
+
+func (driver S3) Path(path string, account *model.Account) (*model.File, []model.File, error) {
+	log.Debugf("s3 path: %s", path)
+	file, err := driver.File(path, account)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !file.IsDir() {
+		link, err := driver.Link(path, account)
+		if err != nil {
+			return nil, nil, err
+		}
+		file.Url = link.Url
+		return file, nil, nil
+	}
+	files, err := driver.Files(path, account)
+	if err != nil {
+		return nil, nil, err
+	}
+	model.SortFiles(files, account)
+	return nil, files, nil
+}
+
+func (driver S3) Proxy(c *gin.Context, account *model.Account) {
+
+}
+
+func (driver S3) Preview(path string, account *model.Account) (interface{}, error) {
+	return nil, base.ErrNotSupport
+}
+
+func (driver S3) Search(path string, keyword string, account *model.Account) ([]*model.File, error) {
+	return nil, base.ErrNotSupport
+}
+
+// MakeDir create an empty object ends with "/" as the placeholder of the folder
+func (driver S3) MakeDir(path string, account *model.Account) error {
+	client, err := driver.client(account)
+	if err != nil {
+		return err
+	}
+	_, err = client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(account.Bucket),
+		Key:    aws.String(getKey(path, account, true)),
+		Body:   strings.NewReader(""),
+	})
+	return err
+}
+
+// Move copy and then delete the objects, S3 can't rename
+func (driver S3) Move(src string, dst string, account *model.Account) error {
+	if err := driver.copyAll(account, src, dst); err != nil {
+		return err
+	}
+	return driver.deleteAll(account, src)
+}
+
+func (driver S3) Copy(src string, dst string, account *model.Account) error {
+	return driver.copyAll(account, src, dst)
+}
+
+func (driver S3) Delete(path string, account *model.Account) error {
+	return driver.deleteAll(account, path)
+}
+
+// Upload upload with multipart, the parts are uploaded concurrently
+func (driver S3) Upload(file *model.FileStream, account *model.Account) error {
+	sess, err := driver.getSession(account)
+	if err != nil {
+		return err
+	}
+	uploader := s3manager.NewUploader(sess)
+	input := &s3manager.UploadInput{
+		Bucket: aws.String(account.Bucket),
+		Key:    aws.String(getKey(utils.Join(file.ParentPath, file.Name), account, false)),
+		Body:   file,
+	}
+	if file.MIMEType != "" {
+		input.ContentType = aws.String(file.MIMEType)
+	}
+	_, err = uploader.Upload(input)
+	return err
+}
+
+var _ base.Driver = (*S3)(nil)
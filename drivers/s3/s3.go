@@ -0,0 +1,184 @@
+package s3
+
+import (
+	"fmt"
+	"github.com/Xhofe/alist/drivers/base"
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/utils"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultLinkExpire 预签名链接默认的有效期
+	DefaultLinkExpire = 4 * time.Hour
+	// MaxLinkExpire 签名v4的预签名链接最长有效7天
+	MaxLinkExpire = 7 * 24 * time.Hour
+)
+
+var sessions = map[uint]*session.Session{}
+var sessionKeys = map[uint]string{}
+var sessionsLock sync.Mutex
+
+// linkExpire get the time the presigned links of the account are valid
+func linkExpire(account *model.Account) time.Duration {
+	if account.LinkExpire <= 0 {
+		return DefaultLinkExpire
+	}
+	expire := time.Duration(account.LinkExpire) * time.Minute
+	if expire > MaxLinkExpire {
+		return MaxLinkExpire
+	}
+	return expire
+}
+
+func sessionKey(account *model.Account) string {
+	return fmt.Sprintf("%s\n%s\n%s\n%s\n%v", account.Endpoint, account.Region, account.AccessKey,
+		account.AccessSecret, account.PathStyle)
+}
+
+// getSession get the session of the account, create a new one if the config changed
+func (driver S3) getSession(account *model.Account) (*session.Session, error) {
+	sessionsLock.Lock()
+	defer sessionsLock.Unlock()
+	key := sessionKey(account)
+	if sess, ok := sessions[account.ID]; ok && sessionKeys[account.ID] == key {
+		return sess, nil
+	}
+	config := &aws.Config{
+		Credentials:      credentials.NewStaticCredentials(account.AccessKey, account.AccessSecret, ""),
+		Region:           aws.String(account.Region),
+		S3ForcePathStyle: aws.Bool(account.PathStyle),
+	}
+	if account.Endpoint != "" {
+		config.Endpoint = aws.String(account.Endpoint)
+	}
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, err
+	}
+	sessions[account.ID] = sess
+	sessionKeys[account.ID] = key
+	return sess, nil
+}
+
+func (driver S3) client(account *model.Account) (*s3.S3, error) {
+	sess, err := driver.getSession(account)
+	if err != nil {
+		return nil, err
+	}
+	return s3.New(sess), nil
+}
+
+// getKey get the object key of the path, the folder key ends with "/"
+func getKey(path string, account *model.Account, dir bool) string {
+	key := strings.TrimPrefix(utils.Join(account.RootFolder, path), "/")
+	if dir && key != "" {
+		key += "/"
+	}
+	return key
+}
+
+// listAll list all the objects under the prefix recursively
+func listAll(client *s3.S3, account *model.Account, prefix string, fn func(object *s3.Object) error) error {
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(account.Bucket),
+		Prefix: aws.String(prefix),
+	}
+	for {
+		output, err := client.ListObjectsV2(input)
+		if err != nil {
+			return err
+		}
+		for _, object := range output.Contents {
+			if err = fn(object); err != nil {
+				return err
+			}
+		}
+		if !aws.BoolValue(output.IsTruncated) {
+			return nil
+		}
+		input.ContinuationToken = output.NextContinuationToken
+	}
+}
+
+func copyObject(client *s3.S3, account *model.Account, src string, dst string) error {
+	_, err := client.CopyObject(&s3.CopyObjectInput{
+		Bucket:     aws.String(account.Bucket),
+		CopySource: aws.String(url.PathEscape(account.Bucket + "/" + src)),
+		Key:        aws.String(dst),
+	})
+	return err
+}
+
+// copyAll copy the file or the folder with all the objects under it
+func (driver S3) copyAll(account *model.Account, src string, dst string) error {
+	client, err := driver.client(account)
+	if err != nil {
+		return err
+	}
+	file, err := driver.File(src, account)
+	if err != nil {
+		return err
+	}
+	if !file.IsDir() {
+		return copyObject(client, account, getKey(src, account, false), getKey(dst, account, false))
+	}
+	srcPrefix, dstPrefix := getKey(src, account, true), getKey(dst, account, true)
+	return listAll(client, account, srcPrefix, func(object *s3.Object) error {
+		return copyObject(client, account, *object.Key, dstPrefix+strings.TrimPrefix(*object.Key, srcPrefix))
+	})
+}
+
+// deleteAll delete the file or the folder with all the objects under it
+func (driver S3) deleteAll(account *model.Account, path string) error {
+	client, err := driver.client(account)
+	if err != nil {
+		return err
+	}
+	file, err := driver.File(path, account)
+	if err != nil {
+		return err
+	}
+	if !file.IsDir() {
+		_, err = client.DeleteObject(&s3.DeleteObjectInput{
+			Bucket: aws.String(account.Bucket),
+			Key:    aws.String(getKey(path, account, false)),
+		})
+		return err
+	}
+	// 每次最多删除1000个
+	objects := make([]*s3.ObjectIdentifier, 0)
+	deleteObjects := func() error {
+		if len(objects) == 0 {
+			return nil
+		}
+		_, err := client.DeleteObjects(&s3.DeleteObjectsInput{
+			Bucket: aws.String(account.Bucket),
+			Delete: &s3.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		objects = objects[:0]
+		return err
+	}
+	err = listAll(client, account, getKey(path, account, true), func(object *s3.Object) error {
+		objects = append(objects, &s3.ObjectIdentifier{Key: object.Key})
+		if len(objects) == 1000 {
+			return deleteObjects()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return deleteObjects()
+}
+
+func init() {
+	base.RegisterDriver(&S3{})
+}
@@ -0,0 +1,257 @@
+package sftp
+
+import (
+	"github.com/Xhofe/alist/conf"
+	"github.com/Xhofe/alist/drivers/base"
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"path/filepath"
+)
+
+type SFTP struct{}
+
+func (driver SFTP) Config() base.DriverConfig {
+	return base.DriverConfig{
+		Name:      "SFTP",
+		OnlyProxy: true,
+		NoLink:    true,
+	}
+}
+
+func (driver SFTP) Items() []base.Item {
+	return []base.Item{
+		{
+			Name:        "site_url",
+			Label:       "host:port",
+			Type:        base.TypeString,
+			Required:    true,
+			Description: "such as example.com:22",
+		},
+		{
+			Name:     "username",
+			Label:    "username",
+			Type:     base.TypeString,
+			Required: true,
+		},
+		{
+			Name:        "password",
+			Label:       "password",
+			Type:        base.TypeString,
+			Required:    false,
+			Description: "the password of the user, or the passphrase of the private key",
+		},
+		{
+			Name:        "private_key",
+			Label:       "private key",
+			Type:        base.TypeString,
+			Required:    false,
+			Description: "PEM private key, login with the password if empty",
+		},
+		{
+			Name:        "host_key",
+			Label:       "host key",
+			Type:        base.TypeString,
+			Required:    false,
+			Description: "the public key of the host like in known_hosts(e.g. ssh-ed25519 AAAA...) or its SHA256 fingerprint, trust and save the key of the first connection if empty",
+		},
+		{
+			Name:     "root_folder",
+			Label:    "root folder path",
+			Type:     base.TypeString,
+			Required: false,
+		},
+		{
+			Name:     "order_by",
+			Label:    "order_by",
+			Type:     base.TypeSelect,
+			Values:   "name,size,updated_at",
+			Required: false,
+		},
+		{
+			Name:     "order_direction",
+			Label:    "order_direction",
+			Type:     base.TypeSelect,
+			Values:   "ASC,DESC",
+			Required: false,
+		},
+	}
+}
+
+func (driver SFTP) Save(account *model.Account, old *model.Account) error {
+	if account.RootFolder == "" {
+		account.RootFolder = "/"
+	}
+	closeClient(account)
+	err := driver.withClient(account, func(c *client) error {
+		_, err := c.Stat(account.RootFolder)
+		return err
+	})
+	if err != nil {
+		account.Status = err.Error()
+	} else {
+		account.Status = "work"
+	}
+	_ = model.SaveAccount(account)
+	return err
+}
+
+func (driver SFTP) File(path string, account *model.Account) (*model.File, error) {
+	log.Debugf("file: %s", path)
+	path = utils.ParsePath(path)
+	if path == "/" {
+		return &model.File{
+			Id:        account.RootFolder,
+			Name:      account.Name,
+			Size:      0,
+			Type:      conf.FOLDER,
+			Driver:    driver.Config().Name,
+			UpdatedAt: account.UpdatedAt,
+		}, nil
+	}
+	dir, name := filepath.Split(path)
+	files, err := driver.Files(dir, account)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if file.Name == name {
+			return &file, nil
+		}
+	}
+	return nil, base.ErrPathNotFound
+}
+
+func (driver SFTP) Files(path string, account *model.Account) ([]model.File, error) {
+	log.Debugf("files: %s", path)
+	path = utils.ParsePath(path)
+	var cache []model.File
+	err := base.GetCache(path, account, &cache)
+	if err == nil {
+		return cache, nil
+	}
+	realPath := utils.Join(account.RootFolder, path)
+	res := make([]model.File, 0)
+	err = driver.withClient(account, func(c *client) error {
+		entries, err := c.ReadDir(realPath)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			updatedAt := entry.ModTime()
+			f := model.File{
+				Name:      entry.Name(),
+				Size:      entry.Size(),
+				UpdatedAt: &updatedAt,
+				Driver:    driver.Config().Name,
+			}
+			if entry.IsDir() {
+				f.Type = conf.FOLDER
+			} else {
+				f.Type = utils.GetFileType(filepath.Ext(entry.Name()))
+			}
+			res = append(res, f)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	_ = base.SetCache(path, res, account)
+	return res, nil
+}
+
+func (driver SFTP) Link(path string, account *model.Account) (*base.Link, error) {
+	path = utils.ParsePath(path)
+	realPath := utils.Join(account.RootFolder, path)
+	file, err := driver.File(path, account)
+	if err != nil {
+		return nil, err
+	}
+	if file.IsDir() {
+		return nil, base.ErrNotFile
+	}
+	return &base.Link{
+		Reader: func(offset int64) (io.ReadCloser, error) {
+			return driver.Open(realPath, offset, account)
+		},
+	}, nil
+}
+
+func (driver SFTP) Path(path string, account *model.Account) (*model.File, []model.File, error) {
+	log.Debugf("sftp path: %s", path)
+	file, err := driver.File(path, account)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !file.IsDir() {
+		return file, nil, nil
+	}
+	files, err := driver.Files(path, account)
+	if err != nil {
+		return nil, nil, err
+	}
+	model.SortFiles(files, account)
+	return nil, files, nil
+}
+
+func (driver SFTP) Proxy(c *gin.Context, account *model.Account) {
+
+}
+
+func (driver SFTP) Preview(path string, account *model.Account) (interface{}, error) {
+	return nil, base.ErrNotSupport
+}
+
+func (driver SFTP) Search(path string, keyword string, account *model.Account) ([]*model.File, error) {
+	return nil, base.ErrNotSupport
+}
+
+func (driver SFTP) MakeDir(path string, account *model.Account) error {
+	realPath := utils.Join(account.RootFolder, path)
+	return driver.withClient(account, func(c *client) error {
+		return c.MkdirAll(realPath)
+	})
+}
+
+func (driver SFTP) Move(src string, dst string, account *model.Account) error {
+	realSrc := utils.Join(account.RootFolder, src)
+	realDst := utils.Join(account.RootFolder, dst)
+	return driver.withClient(account, func(c *client) error {
+		return c.Rename(realSrc, realDst)
+	})
+}
+
+func (driver SFTP) Copy(src string, dst string, account *model.Account) error {
+	realSrc := utils.Join(account.RootFolder, src)
+	realDst := utils.Join(account.RootFolder, dst)
+	return driver.withClient(account, func(c *client) error {
+		return copyAll(c, realSrc, realDst)
+	})
+}
+
+func (driver SFTP) Delete(path string, account *model.Account) error {
+	realPath := utils.Join(account.RootFolder, path)
+	return driver.withClient(account, func(c *client) error {
+		return removeAll(c, realPath)
+	})
+}
+
+func (driver SFTP) Upload(file *model.FileStream, account *model.Account) error {
+	realPath := utils.Join(account.RootFolder, file.ParentPath, file.Name)
+	return driver.withClient(account, func(c *client) error {
+		f, err := c.Create(realPath)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		_, err = f.ReadFrom(file)
+		return err
+	})
+}
+
+var _ base.Driver = (*SFTP)(nil)
@@ -0,0 +1,320 @@
+package sftp
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Xhofe/alist/drivers/base"
+	"github.com/Xhofe/alist/model"
+	"github.com/pkg/sftp"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/sync/singleflight"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// client 每个账号共用一个sftp连接，sftp支持在一个连接上并发请求
+type client struct {
+	*sftp.Client
+	key     string // 账号的连接信息，变化后重新连接
+	ssh     *ssh.Client
+	refs    int  // 正在使用的次数，受clientsLock保护
+	retired bool // 已被替换，不再使用后关闭
+}
+
+var clients = map[uint]*client{}
+var clientsLock sync.Mutex
+
+// dialGroup 同一账号同时只建立一个连接，连接时不持有clientsLock
+var dialGroup singleflight.Group
+
+func clientKey(account *model.Account) string {
+	return fmt.Sprintf("%s\n%s\n%s\n%s\n%s", account.SiteUrl, account.Username, account.Password, account.PrivateKey, account.HostKey)
+}
+
+// hostKeyAlgorithms only negotiate the algorithms of the known host key
+func hostKeyAlgorithms(key ssh.PublicKey) []string {
+	if key.Type() == ssh.KeyAlgoRSA {
+		return []string{ssh.SigAlgoRSASHA2512, ssh.SigAlgoRSASHA2256, ssh.SigAlgoRSA}
+	}
+	return []string{key.Type()}
+}
+
+// hostKeyCallback verify the host key with the one of the account, which is either a public key
+// or a SHA256 fingerprint. The key of the first connection is trusted and saved if not set.
+func hostKeyCallback(account *model.Account, known ssh.PublicKey) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		fingerprint := ssh.FingerprintSHA256(key)
+		switch {
+		case account.HostKey == "":
+			// 其他连接可能已经保存了主机公钥
+			hostKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+			trusted := hostKey
+			err := model.UpdateAccountFields(account.ID, func(a *model.Account) {
+				if a.HostKey == "" {
+					a.HostKey = hostKey
+				}
+				trusted = a.HostKey
+			}, "host_key")
+			if err != nil && err != model.ErrAccountNotFound {
+				return err
+			}
+			if trusted == hostKey || trusted == fingerprint {
+				log.Infof("trust the host key of sftp account [%s]: %s", account.Name, fingerprint)
+				account.HostKey = trusted
+				return nil
+			}
+		case known != nil:
+			if bytes.Equal(known.Marshal(), key.Marshal()) {
+				return nil
+			}
+		case account.HostKey == fingerprint:
+			return nil
+		}
+		return fmt.Errorf("host key of %s mismatch, got %s", hostname, fingerprint)
+	}
+}
+
+func (driver SFTP) Login(account *model.Account) (*client, error) {
+	auth := make([]ssh.AuthMethod, 0)
+	if account.PrivateKey != "" {
+		var signer ssh.Signer
+		var err error
+		if account.Password != "" {
+			// 有密码时作为私钥的密码
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(account.PrivateKey), []byte(account.Password))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(account.PrivateKey))
+		}
+		if err != nil {
+			return nil, err
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	} else {
+		auth = append(auth, ssh.Password(account.Password))
+	}
+	var known ssh.PublicKey
+	if strings.HasPrefix(account.HostKey, "SHA256:") {
+		// 指纹在下面验证
+	} else if account.HostKey != "" {
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(account.HostKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid host key: %w", err)
+		}
+		known = key
+	}
+	config := &ssh.ClientConfig{
+		User:            account.Username,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback(account, known),
+		Timeout:         10 * time.Second,
+	}
+	if known != nil {
+		config.HostKeyAlgorithms = hostKeyAlgorithms(known)
+	}
+	sshClient, err := ssh.Dial("tcp", account.SiteUrl, config)
+	if err != nil {
+		return nil, err
+	}
+	sftpClient, err := sftp.NewClient(sshClient)
+	if err != nil {
+		_ = sshClient.Close()
+		return nil, err
+	}
+	return &client{Client: sftpClient, key: clientKey(account), ssh: sshClient}, nil
+}
+
+func (c *client) close() {
+	_ = c.Client.Close()
+	_ = c.ssh.Close()
+}
+
+// retire close the client after all the users released it, must be called with clientsLock held
+func (c *client) retire() {
+	c.retired = true
+	if c.refs == 0 {
+		c.close()
+	}
+}
+
+// getClient get the client of the account and hold it, putClient must be called after used
+func (driver SFTP) getClient(account *model.Account) (*client, error) {
+	key := clientKey(account)
+	clientsLock.Lock()
+	if c, ok := clients[account.ID]; ok && c.key == key {
+		c.refs++
+		clientsLock.Unlock()
+		return c, nil
+	}
+	clientsLock.Unlock()
+	v, err, _ := dialGroup.Do(fmt.Sprintf("%d\n%s", account.ID, key), func() (interface{}, error) {
+		c, err := driver.Login(account)
+		if err != nil {
+			return nil, err
+		}
+		clientsLock.Lock()
+		defer clientsLock.Unlock()
+		if cur, ok := clients[account.ID]; ok {
+			// 连接期间已经有了可用的连接
+			if cur.key == c.key {
+				c.close()
+				return cur, nil
+			}
+			cur.retire()
+		}
+		clients[account.ID] = c
+		return c, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	c := v.(*client)
+	clientsLock.Lock()
+	defer clientsLock.Unlock()
+	if c.retired {
+		return nil, fmt.Errorf("sftp client of [%s] closed", account.Name)
+	}
+	c.refs++
+	return c, nil
+}
+
+// putClient release the client, the client is dropped if the error is not a sftp status, reconnect next time
+func putClient(account *model.Account, c *client, err error) {
+	clientsLock.Lock()
+	defer clientsLock.Unlock()
+	c.refs--
+	if err != nil {
+		if _, ok := err.(*sftp.StatusError); !ok && !os.IsNotExist(err) && !os.IsExist(err) && !os.IsPermission(err) {
+			log.Debugf("sftp client of [%s] closed: %s", account.Name, err.Error())
+			if clients[account.ID] == c {
+				delete(clients, account.ID)
+			}
+			c.retired = true
+		}
+	}
+	if c.retired && c.refs == 0 {
+		c.close()
+	}
+}
+
+// withClient run the function with the client of the account
+func (driver SFTP) withClient(account *model.Account, fn func(c *client) error) error {
+	c, err := driver.getClient(account)
+	if err != nil {
+		return err
+	}
+	err = fn(c)
+	putClient(account, c, err)
+	return err
+}
+
+// closeClient drop the client of the account, the downloading files are not interrupted
+func closeClient(account *model.Account) {
+	clientsLock.Lock()
+	defer clientsLock.Unlock()
+	if c, ok := clients[account.ID]; ok {
+		delete(clients, account.ID)
+		c.retire()
+	}
+}
+
+// clientFile 关闭文件时释放连接
+type clientFile struct {
+	*sftp.File
+	account *model.Account
+	client  *client
+}
+
+func (f *clientFile) Close() error {
+	err := f.File.Close()
+	putClient(f.account, f.client, err)
+	return err
+}
+
+// Open open the file and seek to the offset, the client is held until the file closed
+func (driver SFTP) Open(realPath string, offset int64, account *model.Account) (io.ReadCloser, error) {
+	c, err := driver.getClient(account)
+	if err != nil {
+		return nil, err
+	}
+	f, err := c.Open(realPath)
+	if err != nil {
+		putClient(account, c, err)
+		return nil, err
+	}
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		_ = f.Close()
+		putClient(account, c, err)
+		return nil, err
+	}
+	return &clientFile{File: f, account: account, client: c}, nil
+}
+
+// removeAll remove the file or the folder recursively
+func removeAll(c *client, realPath string) error {
+	stat, err := c.Stat(realPath)
+	if err != nil {
+		return err
+	}
+	if !stat.IsDir() {
+		return c.Remove(realPath)
+	}
+	entries, err := c.ReadDir(realPath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err = removeAll(c, path.Join(realPath, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return c.RemoveDirectory(realPath)
+}
+
+// copyAll copy the file or the folder recursively, the data is transferred through this server
+func copyAll(c *client, src string, dst string) error {
+	stat, err := c.Stat(src)
+	if err != nil {
+		return err
+	}
+	if stat.IsDir() {
+		if err = c.MkdirAll(dst); err != nil {
+			return err
+		}
+		entries, err := c.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err = copyAll(c, path.Join(src, entry.Name()), path.Join(dst, entry.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	srcFile, err := c.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = srcFile.Close()
+	}()
+	dstFile, err := c.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = dstFile.Close()
+	}()
+	_, err = dstFile.ReadFrom(srcFile)
+	return err
+}
+
+func init() {
+	base.RegisterDriver(&SFTP{})
+}
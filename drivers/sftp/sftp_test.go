@@ -0,0 +1,278 @@
+package sftp
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/utils/testdb"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// testServer 进程内的ssh服务器，提供sftp子系统
+type testServer struct {
+	addr    string
+	hostKey ssh.Signer
+	logins  int32
+}
+
+func newSigner(t *testing.T) ssh.Signer {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func startServer(t *testing.T) *testServer {
+	s := &testServer{hostKey: newSigner(t)}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == "user" && string(password) == "pass" {
+				atomic.AddInt32(&s.logins, 1)
+				return nil, nil
+			}
+			return nil, os.ErrPermission
+		},
+	}
+	config.AddHostKey(s.hostKey)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	s.addr = listener.Addr().String()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveConn(conn, config)
+		}
+	}()
+	return s
+}
+
+func serveConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChan := range chans {
+		if newChan.ChannelType() != "session" {
+			_ = newChan.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChan.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range requests {
+				ok := req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+				_ = req.Reply(ok, nil)
+				if !ok {
+					continue
+				}
+				server, err := sftp.NewServer(channel)
+				if err != nil {
+					_ = channel.Close()
+					return
+				}
+				_ = server.Serve()
+				_ = channel.Close()
+			}
+		}()
+	}
+}
+
+func initTest(t *testing.T) {
+	testdb.Init(t, &model.Account{})
+}
+
+func newAccount(t *testing.T, s *testServer, root string) *model.Account {
+	account := &model.Account{
+		Name:       t.Name(),
+		Type:       "SFTP",
+		SiteUrl:    s.addr,
+		Username:   "user",
+		Password:   "pass",
+		RootFolder: root,
+	}
+	if err := model.CreateAccount(account); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		closeClient(account)
+		model.UnregisterAccount(account.Name)
+	})
+	return account
+}
+
+func TestReadDir(t *testing.T) {
+	initTest(t)
+	s := startServer(t)
+	root := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(root, "a.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	account := newAccount(t, s, root)
+	var driver SFTP
+	err := driver.withClient(account, func(c *client) error {
+		entries, err := c.ReadDir(root)
+		if err != nil {
+			return err
+		}
+		if len(entries) != 1 || entries[0].Name() != "a.txt" || entries[0].Size() != 5 {
+			t.Errorf("unexpected entries: %v", entries)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := driver.Open(filepath.Join(root, "a.txt"), 1, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(r)
+	_ = r.Close()
+	if err != nil || string(data) != "ello" {
+		t.Errorf("read %q, %v", data, err)
+	}
+}
+
+// 首次连接时信任并保存主机公钥，之后只接受这个公钥
+func TestHostKey(t *testing.T) {
+	initTest(t)
+	s := startServer(t)
+	account := newAccount(t, s, "/")
+	var driver SFTP
+	c, err := driver.Login(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.close()
+	want := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(s.hostKey.PublicKey())))
+	saved, err := model.GetAccountById(account.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registered, _ := model.GetAccount(account.Name)
+	if account.HostKey != want || saved.HostKey != want || registered.HostKey != want {
+		t.Fatalf("host key not saved: %q, %q, %q", account.HostKey, saved.HostKey, registered.HostKey)
+	}
+	other := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(newSigner(t).PublicKey())))
+	tests := []struct {
+		name    string
+		hostKey string
+		ok      bool
+	}{
+		{"public key", want, true},
+		{"fingerprint", ssh.FingerprintSHA256(s.hostKey.PublicKey()), true},
+		{"other key", other, false},
+		{"other fingerprint", ssh.FingerprintSHA256(newSigner(t).PublicKey()), false},
+		{"invalid", "not a key", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acc := *account
+			acc.HostKey = tt.hostKey
+			c, err := driver.Login(&acc)
+			if err == nil {
+				c.close()
+			}
+			if (err == nil) != tt.ok {
+				t.Errorf("login with host key %q: %v", tt.hostKey, err)
+			}
+		})
+	}
+}
+
+// 并发获取连接时只建立一个连接
+func TestGetClientConcurrently(t *testing.T) {
+	initTest(t)
+	s := startServer(t)
+	account := newAccount(t, s, "/")
+	account.HostKey = ssh.FingerprintSHA256(s.hostKey.PublicKey())
+	var driver SFTP
+	var wg sync.WaitGroup
+	clients := make([]*client, 10)
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			acc := *account
+			c, err := driver.getClient(&acc)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			clients[i] = c
+		}(i)
+	}
+	wg.Wait()
+	for _, c := range clients {
+		if c != nil {
+			putClient(account, c, nil)
+		}
+	}
+	if logins := atomic.LoadInt32(&s.logins); logins != 1 {
+		t.Errorf("logged in %d times, want 1", logins)
+	}
+}
+
+// 保存账号时正在下载的文件不会被中断，文件关闭后旧连接才关闭
+func TestOpenAcrossSave(t *testing.T) {
+	initTest(t)
+	s := startServer(t)
+	root := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(root, "a.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	account := newAccount(t, s, root)
+	var driver SFTP
+	r, err := driver.Open(filepath.Join(root, "a.txt"), 0, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := r.(*clientFile).client
+	if err = driver.Save(account, account); err != nil {
+		t.Fatal(err)
+	}
+	if account.Status != "work" {
+		t.Fatalf("status = %s", account.Status)
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil || string(data) != "hello" {
+		t.Errorf("read %q, %v", data, err)
+	}
+	if _, err = old.Getwd(); err != nil {
+		t.Errorf("the old client is closed before the file: %v", err)
+	}
+	_ = r.Close()
+	if _, err = old.Getwd(); err == nil {
+		t.Errorf("the old client is not closed after the file")
+	}
+	if logins := atomic.LoadInt32(&s.logins); logins != 2 {
+		t.Errorf("logged in %d times, want 2", logins)
+	}
+}
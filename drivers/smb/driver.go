@@ -0,0 +1,267 @@
+package smb
+
+import (
+	"github.com/Xhofe/alist/conf"
+	"github.com/Xhofe/alist/drivers/base"
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"path/filepath"
+)
+
+type SMB struct{}
+
+func (driver SMB) Config() base.DriverConfig {
+	return base.DriverConfig{
+		Name:      "SMB",
+		OnlyProxy: true,
+		NoLink:    true,
+	}
+}
+
+func (driver SMB) Items() []base.Item {
+	return []base.Item{
+		{
+			Name:        "site_url",
+			Label:       "smb host",
+			Type:        base.TypeString,
+			Required:    true,
+			Description: "host:port, the port is 445 if omitted",
+		},
+		{
+			Name:     "share",
+			Label:    "share name",
+			Type:     base.TypeString,
+			Required: true,
+		},
+		{
+			Name:     "username",
+			Label:    "username",
+			Type:     base.TypeString,
+			Required: false,
+		},
+		{
+			Name:     "password",
+			Label:    "password",
+			Type:     base.TypeString,
+			Required: false,
+		},
+		{
+			Name:     "domain",
+			Label:    "domain",
+			Type:     base.TypeString,
+			Required: false,
+		},
+		{
+			Name:     "root_folder",
+			Label:    "root folder path",
+			Type:     base.TypeString,
+			Required: false,
+		},
+		{
+			Name:        "max_connections",
+			Label:       "max connections",
+			Type:        base.TypeNumber,
+			Required:    false,
+			Description: "max sessions in the session pool, default 5",
+		},
+		{
+			Name:        "idle_timeout",
+			Label:       "idle timeout",
+			Type:        base.TypeNumber,
+			Required:    false,
+			Description: "seconds the idle sessions are kept in the pool, default 60",
+		},
+		{
+			Name:     "order_by",
+			Label:    "order_by",
+			Type:     base.TypeSelect,
+			Values:   "name,size,updated_at",
+			Required: false,
+		},
+		{
+			Name:     "order_direction",
+			Label:    "order_direction",
+			Type:     base.TypeSelect,
+			Values:   "ASC,DESC",
+			Required: false,
+		},
+	}
+}
+
+func (driver SMB) Save(account *model.Account, old *model.Account) error {
+	if account.RootFolder == "" {
+		account.RootFolder = "/"
+	}
+	base.ClosePool(account)
+	err := driver.withConn(account, func(c *conn) error {
+		_, err := c.share.Stat(getPath("/", account))
+		return err
+	})
+	if err != nil {
+		account.Status = err.Error()
+	} else {
+		account.Status = "work"
+	}
+	_ = model.SaveAccount(account)
+	return err
+}
+
+func (driver SMB) File(path string, account *model.Account) (*model.File, error) {
+	path = utils.ParsePath(path)
+	if path == "/" {
+		return &model.File{
+			Id:        account.RootFolder,
+			Name:      account.Name,
+			Size:      0,
+			Type:      conf.FOLDER,
+			Driver:    driver.Config().Name,
+			UpdatedAt: account.UpdatedAt,
+		}, nil
+	}
+	dir, name := filepath.Split(path)
+	files, err := driver.Files(dir, account)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if file.Name == name {
+			return &file, nil
+		}
+	}
+	return nil, base.ErrPathNotFound
+}
+
+func (driver SMB) Files(path string, account *model.Account) ([]model.File, error) {
+	log.Debugf("files: %s", path)
+	path = utils.ParsePath(path)
+	var cache []model.File
+	err := base.GetCache(path, account, &cache)
+	if err == nil {
+		return cache, nil
+	}
+	realPath := getPath(path, account)
+	res := make([]model.File, 0)
+	err = driver.withConn(account, func(c *conn) error {
+		entries, err := c.share.ReadDir(realPath)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			updatedAt := entry.ModTime()
+			f := model.File{
+				Name:      entry.Name(),
+				Size:      entry.Size(),
+				UpdatedAt: &updatedAt,
+				Driver:    driver.Config().Name,
+			}
+			if entry.IsDir() {
+				f.Type = conf.FOLDER
+			} else {
+				f.Type = utils.GetFileType(filepath.Ext(entry.Name()))
+			}
+			res = append(res, f)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	_ = base.SetCache(path, res, account)
+	return res, nil
+}
+
+func (driver SMB) Link(path string, account *model.Account) (*base.Link, error) {
+	path = utils.ParsePath(path)
+	realPath := getPath(path, account)
+	file, err := driver.File(path, account)
+	if err != nil {
+		return nil, err
+	}
+	if file.IsDir() {
+		return nil, base.ErrNotFile
+	}
+	return &base.Link{
+		Reader: func(offset int64) (io.ReadCloser, error) {
+			return driver.Open(realPath, offset, account)
+		},
+	}, nil
+}
+
+func (driver SMB) Path(path string, account *model.Account) (*model.File, []model.File, error) {
+	log.Debugf("smb path: %s", path)
+	file, err := driver.File(path, account)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !file.IsDir() {
+		return file, nil, nil
+	}
+	files, err := driver.Files(path, account)
+	if err != nil {
+		return nil, nil, err
+	}
+	model.SortFiles(files, account)
+	return nil, files, nil
+}
+
+func (driver SMB) Proxy(c *gin.Context, account *model.Account) {
+
+}
+
+func (driver SMB) Preview(path string, account *model.Account) (interface{}, error) {
+	return nil, base.ErrNotSupport
+}
+
+func (driver SMB) Search(path string, keyword string, account *model.Account) ([]*model.File, error) {
+	return nil, base.ErrNotSupport
+}
+
+func (driver SMB) MakeDir(path string, account *model.Account) error {
+	realPath := getPath(path, account)
+	return driver.withConn(account, func(c *conn) error {
+		return c.share.MkdirAll(realPath, 0755)
+	})
+}
+
+func (driver SMB) Move(src string, dst string, account *model.Account) error {
+	realSrc := getPath(src, account)
+	realDst := getPath(dst, account)
+	return driver.withConn(account, func(c *conn) error {
+		return c.share.Rename(realSrc, realDst)
+	})
+}
+
+func (driver SMB) Copy(src string, dst string, account *model.Account) error {
+	realSrc := getPath(src, account)
+	realDst := getPath(dst, account)
+	return driver.withConn(account, func(c *conn) error {
+		return copyAll(c.share, realSrc, realDst)
+	})
+}
+
+func (driver SMB) Delete(path string, account *model.Account) error {
+	realPath := getPath(path, account)
+	return driver.withConn(account, func(c *conn) error {
+		return c.share.RemoveAll(realPath)
+	})
+}
+
+func (driver SMB) Upload(file *model.FileStream, account *model.Account) error {
+	realPath := getPath(utils.Join(file.ParentPath, file.Name), account)
+	return driver.withConn(account, func(c *conn) error {
+		f, err := c.share.Create(realPath)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		_, err = f.ReadFrom(file)
+		return err
+	})
+}
+
+var _ base.Driver = (*SMB)(nil)
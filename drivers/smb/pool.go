@@ -0,0 +1,50 @@
+package smb
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Xhofe/alist/drivers/base"
+	"github.com/Xhofe/alist/model"
+	"github.com/hirochachacha/go-smb2"
+	"os"
+)
+
+func poolKey(account *model.Account) string {
+	return fmt.Sprintf("%s\n%s\n%s\n%s\n%s", account.SiteUrl, account.Share, account.Username, account.Password, account.Domain)
+}
+
+// broken whether the session can't be used after the error,
+// the errors replied by the server such as not found don't break the session
+func broken(err error) bool {
+	var respErr *smb2.ResponseError
+	if errors.As(err, &respErr) {
+		return false
+	}
+	return !os.IsNotExist(err) && !os.IsExist(err) && !os.IsPermission(err)
+}
+
+func getPool(driver SMB, account *model.Account) *base.Pool {
+	return base.GetPool(account, poolKey(account), func() base.PoolOptions {
+		acc := *account
+		return base.PoolOptions{
+			Dial: func() (interface{}, error) {
+				return driver.Login(&acc)
+			},
+			Close: func(c interface{}) {
+				c.(*conn).close()
+			},
+			Ping: func(c interface{}) error {
+				_, err := c.(*conn).share.Stat("")
+				return err
+			},
+			Broken: broken,
+		}
+	})
+}
+
+// withConn run the function with a session of the pool
+func (driver SMB) withConn(account *model.Account, fn func(c *conn) error) error {
+	return getPool(driver, account).WithConn(func(c interface{}) error {
+		return fn(c.(*conn))
+	})
+}
@@ -0,0 +1,138 @@
+package smb
+
+import (
+	"github.com/Xhofe/alist/drivers/base"
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/utils"
+	"github.com/hirochachacha/go-smb2"
+	"io"
+	"net"
+	"path"
+	"strings"
+	"time"
+)
+
+// conn 一个登录并挂载了共享的smb会话
+type conn struct {
+	tcp     net.Conn
+	session *smb2.Session
+	share   *smb2.Share
+}
+
+func (c *conn) close() {
+	_ = c.share.Umount()
+	_ = c.session.Logoff()
+	_ = c.tcp.Close()
+}
+
+func (driver SMB) Login(account *model.Account) (*conn, error) {
+	addr := account.SiteUrl
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "445")
+	}
+	tcp, err := net.DialTimeout("tcp", addr, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &smb2.Dialer{
+		Initiator: &smb2.NTLMInitiator{
+			User:     account.Username,
+			Password: account.Password,
+			Domain:   account.Domain,
+		},
+	}
+	session, err := dialer.Dial(tcp)
+	if err != nil {
+		_ = tcp.Close()
+		return nil, err
+	}
+	share, err := session.Mount(account.Share)
+	if err != nil {
+		_ = session.Logoff()
+		_ = tcp.Close()
+		return nil, err
+	}
+	return &conn{tcp: tcp, session: session, share: share}, nil
+}
+
+// getPath get the path in the share, which is relative without the leading "/"
+func getPath(path string, account *model.Account) string {
+	return strings.TrimPrefix(utils.Join(account.RootFolder, path), "/")
+}
+
+// smbReader 读取完成后将会话放回会话池
+type smbReader struct {
+	*smb2.File
+	conn *conn
+	pool *base.Pool
+}
+
+func (r *smbReader) Close() error {
+	err := r.File.Close()
+	r.pool.PutStream(r.conn, err)
+	return err
+}
+
+// Open open the file and seek to the offset
+func (driver SMB) Open(realPath string, offset int64, account *model.Account) (io.ReadCloser, error) {
+	pool := getPool(driver, account)
+	v, err := pool.GetStream()
+	if err != nil {
+		return nil, err
+	}
+	c := v.(*conn)
+	f, err := c.share.Open(realPath)
+	if err != nil {
+		pool.PutStream(c, err)
+		return nil, err
+	}
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		_ = f.Close()
+		pool.PutStream(c, err)
+		return nil, err
+	}
+	return &smbReader{File: f, conn: c, pool: pool}, nil
+}
+
+// copyAll copy the file or the folder recursively, files are copied by the server if supported
+func copyAll(share *smb2.Share, src string, dst string) error {
+	stat, err := share.Stat(src)
+	if err != nil {
+		return err
+	}
+	if stat.IsDir() {
+		if err = share.MkdirAll(dst, 0755); err != nil {
+			return err
+		}
+		entries, err := share.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err = copyAll(share, path.Join(src, entry.Name()), path.Join(dst, entry.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	srcFile, err := share.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = srcFile.Close()
+	}()
+	dstFile, err := share.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = dstFile.Close()
+	}()
+	_, err = dstFile.ReadFrom(srcFile)
+	return err
+}
+
+func init() {
+	base.RegisterDriver(&SMB{})
+}
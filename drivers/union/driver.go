@@ -0,0 +1,231 @@
+package union
+
+import (
+	"context"
+	"github.com/Xhofe/alist/conf"
+	"github.com/Xhofe/alist/drivers/base"
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// Union 将多个账号的路径合并为一个文件夹
+type Union struct{}
+
+func (driver Union) Config() base.DriverConfig {
+	return base.DriverConfig{
+		Name:      "Union",
+		OnlyProxy: true,
+		NoLink:    true,
+	}
+}
+
+func (driver Union) Items() []base.Item {
+	return []base.Item{
+		{
+			Name:        "members",
+			Label:       "members",
+			Type:        base.TypeString,
+			Required:    true,
+			Description: "paths to merge separated by ',', such as /ali/movies,/189/movies, the former has higher priority for the same name",
+		},
+		{
+			Name:        "write_target",
+			Label:       "write target",
+			Type:        base.TypeString,
+			Required:    false,
+			Description: "the member to write new files and folders, the first member if empty",
+		},
+		{
+			Name:     "order_by",
+			Label:    "order_by",
+			Type:     base.TypeSelect,
+			Values:   "name,size,updated_at",
+			Required: false,
+		},
+		{
+			Name:     "order_direction",
+			Label:    "order_direction",
+			Type:     base.TypeSelect,
+			Values:   "ASC,DESC",
+			Required: false,
+		},
+	}
+}
+
+func (driver Union) Save(account *model.Account, old *model.Account) error {
+	_, err := getMembers(account)
+	if err == nil {
+		_, err = getTarget(account)
+	}
+	if err != nil {
+		account.Status = err.Error()
+	} else {
+		account.Status = "work"
+	}
+	_ = model.SaveAccount(account)
+	return err
+}
+
+func (driver Union) File(path string, account *model.Account) (*model.File, error) {
+	path = utils.ParsePath(path)
+	if path == "/" {
+		return &model.File{
+			Id:        "root",
+			Name:      account.Name,
+			Size:      0,
+			Type:      conf.FOLDER,
+			Driver:    driver.Config().Name,
+			UpdatedAt: account.UpdatedAt,
+		}, nil
+	}
+	_, file, err := getOwner(path, account)
+	if err != nil {
+		return nil, err
+	}
+	res := *file
+	res.Driver = driver.Config().Name
+	return &res, nil
+}
+
+// Files merge the files of the members, the member not having the folder is skipped
+func (driver Union) Files(path string, account *model.Account) ([]model.File, error) {
+	path = utils.ParsePath(path)
+	members, err := getMembers(account)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]model.File, 0)
+	names := make(map[string]bool)
+	found := false
+	var firstErr error
+	for _, m := range members {
+		files, err := base.GetFiles(m.driver, m.account, m.path(path))
+		if err != nil {
+			log.Debugf("union [%s] member %s%s: %s", account.Name, m.account.Name, m.root, err.Error())
+			if firstErr == nil || firstErr == base.ErrPathNotFound {
+				firstErr = err
+			}
+			continue
+		}
+		found = true
+		for _, file := range files {
+			if names[file.Name] {
+				continue
+			}
+			names[file.Name] = true
+			file.Driver = driver.Config().Name
+			res = append(res, file)
+		}
+	}
+	if !found {
+		return nil, firstErr
+	}
+	return res, nil
+}
+
+func (driver Union) Link(path string, account *model.Account) (*base.Link, error) {
+	path = utils.ParsePath(path)
+	m, file, err := getOwner(path, account)
+	if err != nil {
+		return nil, err
+	}
+	if file.IsDir() {
+		return nil, base.ErrNotFile
+	}
+	link, err := base.GetLink(m.driver, m.account, m.path(path))
+	if err != nil {
+		return nil, err
+	}
+	return &base.Link{
+		Reader: reader(m, m.path(path), link),
+	}, nil
+}
+
+func (driver Union) Path(path string, account *model.Account) (*model.File, []model.File, error) {
+	file, err := driver.File(path, account)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !file.IsDir() {
+		return file, nil, nil
+	}
+	files, err := driver.Files(path, account)
+	if err != nil {
+		return nil, nil, err
+	}
+	model.SortFiles(files, account)
+	return nil, files, nil
+}
+
+func (driver Union) Proxy(c *gin.Context, account *model.Account) {
+
+}
+
+func (driver Union) Preview(path string, account *model.Account) (interface{}, error) {
+	m, _, err := getOwner(utils.ParsePath(path), account)
+	if err != nil {
+		return nil, err
+	}
+	return m.driver.Preview(m.path(path), m.account)
+}
+
+func (driver Union) Search(path string, keyword string, account *model.Account) ([]*model.File, error) {
+	return nil, base.ErrNotSupport
+}
+
+func (driver Union) MakeDir(path string, account *model.Account) error {
+	target, err := getTarget(account)
+	if err != nil {
+		return err
+	}
+	return base.DoMakeDir(target.driver, target.account, target.path(path))
+}
+
+// Move move the file in the member owning it
+func (driver Union) Move(src string, dst string, account *model.Account) error {
+	m, _, err := getOwner(src, account)
+	if err != nil {
+		return err
+	}
+	return base.DoMove(m.driver, m.account, m.path(src), m.path(dst))
+}
+
+// Copy copy the file from the member owning it to the write target
+func (driver Union) Copy(src string, dst string, account *model.Account) error {
+	m, _, err := getOwner(src, account)
+	if err != nil {
+		return err
+	}
+	target, err := getTarget(account)
+	if err != nil {
+		return err
+	}
+	if m.account.Name == target.account.Name {
+		return base.DoCopy(m.driver, m.account, m.path(src), target.path(dst))
+	}
+	return base.Transfer(context.Background(), m.driver, m.account, m.path(src),
+		target.driver, target.account, target.path(dst), &base.Progress{})
+}
+
+// Delete delete the file in the member owning it, the same name in other members shows after that
+func (driver Union) Delete(path string, account *model.Account) error {
+	m, _, err := getOwner(path, account)
+	if err != nil {
+		return err
+	}
+	return base.DoDelete(m.driver, m.account, m.path(path))
+}
+
+func (driver Union) Upload(file *model.FileStream, account *model.Account) error {
+	target, err := getTarget(account)
+	if err != nil {
+		return err
+	}
+	stream := *file
+	stream.ParentPath = target.path(file.ParentPath)
+	return base.DoUpload(target.driver, target.account, &stream)
+}
+
+var _ base.Driver = (*Union)(nil)
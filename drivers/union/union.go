@@ -0,0 +1,155 @@
+package union
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Xhofe/alist/conf"
+	"github.com/Xhofe/alist/drivers/base"
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/utils"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// member 合并的一个路径
+type member struct {
+	account *model.Account
+	driver  base.Driver
+	root    string // 在成员账号中的路径
+}
+
+func (m member) path(path string) string {
+	return utils.Join(m.root, path)
+}
+
+// getMember resolve the path such as /ali/movies to the member by the mount paths of the accounts
+func getMember(rawPath string, account *model.Account) (*member, error) {
+	memberAccount, root, ok := model.GetAccountByPath(rawPath)
+	if !ok {
+		return nil, fmt.Errorf("no account mounted at [%s]", rawPath)
+	}
+	if memberAccount.Type == account.Type {
+		return nil, fmt.Errorf("can't use union account [%s] as a member", memberAccount.Name)
+	}
+	driver, ok := base.GetDriver(memberAccount.Type)
+	if !ok {
+		return nil, fmt.Errorf("no [%s] driver", memberAccount.Type)
+	}
+	return &member{account: &memberAccount, driver: driver, root: root}, nil
+}
+
+// getMembers get the members in priority order
+func getMembers(account *model.Account) ([]member, error) {
+	members := make([]member, 0)
+	for _, p := range strings.Split(account.Members, ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		m, err := getMember(strings.TrimSpace(p), account)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, *m)
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("no members in union [%s]", account.Name)
+	}
+	return members, nil
+}
+
+// getTarget get the member to write, the first member if not set
+func getTarget(account *model.Account) (*member, error) {
+	if strings.TrimSpace(account.WriteTarget) != "" {
+		return getMember(strings.TrimSpace(account.WriteTarget), account)
+	}
+	members, err := getMembers(account)
+	if err != nil {
+		return nil, err
+	}
+	return &members[0], nil
+}
+
+// getOwner get the member with the highest priority which has the file
+func getOwner(path string, account *model.Account) (*member, *model.File, error) {
+	members, err := getMembers(account)
+	if err != nil {
+		return nil, nil, err
+	}
+	var firstErr error
+	for i := range members {
+		m := members[i]
+		file, err := base.GetFile(m.driver, m.account, m.path(path))
+		if err == nil {
+			return &m, file, nil
+		}
+		if firstErr == nil || firstErr == base.ErrPathNotFound {
+			firstErr = err
+		}
+	}
+	return nil, nil, firstErr
+}
+
+// reader wrap the link of the member to a reader, so that the data of all members is served by this server,
+// the link is refreshed if it expired while reading
+func reader(m *member, path string, link *base.Link) func(offset int64) (io.ReadCloser, error) {
+	if link.Reader != nil {
+		return link.Reader
+	}
+	var lock sync.Mutex
+	return func(offset int64) (io.ReadCloser, error) {
+		lock.Lock()
+		l := link
+		lock.Unlock()
+		if l.Data != nil {
+			if offset > int64(len(l.Data)) {
+				offset = int64(len(l.Data))
+			}
+			return ioutil.NopCloser(bytes.NewReader(l.Data[offset:])), nil
+		}
+		if m.account.Type == "Native" {
+			f, err := os.Open(l.Url)
+			if err != nil {
+				return nil, err
+			}
+			if _, err = f.Seek(offset, io.SeekStart); err != nil {
+				_ = f.Close()
+				return nil, err
+			}
+			return f, nil
+		}
+		// Reader没有ctx，调用方关闭返回的body时中断请求
+		res, err := base.RequestLink(conf.Ctx, l, offset)
+		if err == nil && base.IsLinkExpired(res.StatusCode) {
+			_ = res.Body.Close()
+			if l, err = base.RefreshLink(m.driver, m.account, path); err == nil {
+				lock.Lock()
+				link = l
+				lock.Unlock()
+				res, err = base.RequestLink(conf.Ctx, l, offset)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
+			_ = res.Body.Close()
+			return nil, fmt.Errorf("get data of [%s] error: %s", l.Url, res.Status)
+		}
+		// 不支持Range时跳过前面的数据
+		if offset > 0 && res.StatusCode == http.StatusOK {
+			if _, err = io.CopyN(ioutil.Discard, res.Body, offset); err != nil {
+				_ = res.Body.Close()
+				return nil, err
+			}
+		}
+		return res.Body, nil
+	}
+}
+
+func init() {
+	base.RegisterDriver(&Union{})
+}
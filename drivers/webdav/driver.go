@@ -0,0 +1,236 @@
+package webdav
+
+import (
+	"fmt"
+	"github.com/Xhofe/alist/conf"
+	"github.com/Xhofe/alist/drivers/base"
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+type WebDav struct{}
+
+func (driver WebDav) Config() base.DriverConfig {
+	return base.DriverConfig{
+		Name:      "WebDav",
+		OnlyProxy: true,
+		NoLink:    true,
+	}
+}
+
+func (driver WebDav) Items() []base.Item {
+	return []base.Item{
+		{
+			Name:        "site_url",
+			Label:       "webdav url",
+			Type:        base.TypeString,
+			Required:    true,
+			Description: "such as https://example.com/remote.php/dav/files/user",
+		},
+		{
+			Name:     "username",
+			Label:    "username",
+			Type:     base.TypeString,
+			Required: false,
+		},
+		{
+			Name:     "password",
+			Label:    "password",
+			Type:     base.TypeString,
+			Required: false,
+		},
+		{
+			Name:     "root_folder",
+			Label:    "root folder path",
+			Type:     base.TypeString,
+			Required: false,
+		},
+		{
+			Name:     "order_by",
+			Label:    "order_by",
+			Type:     base.TypeSelect,
+			Values:   "name,size,updated_at",
+			Required: false,
+		},
+		{
+			Name:     "order_direction",
+			Label:    "order_direction",
+			Type:     base.TypeSelect,
+			Values:   "ASC,DESC",
+			Required: false,
+		},
+	}
+}
+
+func (driver WebDav) Save(account *model.Account, old *model.Account) error {
+	if account.RootFolder == "" {
+		account.RootFolder = "/"
+	}
+	_, err := propfind("/", account)
+	if err != nil {
+		account.Status = err.Error()
+	} else {
+		account.Status = "work"
+	}
+	_ = model.SaveAccount(account)
+	return err
+}
+
+func (driver WebDav) File(path string, account *model.Account) (*model.File, error) {
+	path = utils.ParsePath(path)
+	if path == "/" {
+		return &model.File{
+			Id:        account.RootFolder,
+			Name:      account.Name,
+			Size:      0,
+			Type:      conf.FOLDER,
+			Driver:    driver.Config().Name,
+			UpdatedAt: account.UpdatedAt,
+		}, nil
+	}
+	dir, name := filepath.Split(path)
+	files, err := driver.Files(dir, account)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if file.Name == name {
+			return &file, nil
+		}
+	}
+	return nil, base.ErrPathNotFound
+}
+
+func (driver WebDav) Files(path string, account *model.Account) ([]model.File, error) {
+	path = utils.ParsePath(path)
+	var cache []model.File
+	err := base.GetCache(path, account, &cache)
+	if err == nil {
+		return cache, nil
+	}
+	entries, err := propfind(path, account)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(getUrl(path, account))
+	if err != nil {
+		return nil, err
+	}
+	self := strings.TrimRight(u.Path, "/")
+	res := make([]model.File, 0)
+	for _, e := range entries {
+		// 跳过文件夹本身
+		if e.path == self {
+			continue
+		}
+		name := e.path[strings.LastIndex(e.path, "/")+1:]
+		f := model.File{
+			Name:      name,
+			Size:      e.size,
+			Driver:    driver.Config().Name,
+			UpdatedAt: e.modTime,
+		}
+		if e.isDir {
+			f.Type = conf.FOLDER
+		} else {
+			f.Type = utils.GetFileType(filepath.Ext(name))
+		}
+		res = append(res, f)
+	}
+	_ = base.SetCache(path, res, account)
+	return res, nil
+}
+
+func (driver WebDav) Link(path string, account *model.Account) (*base.Link, error) {
+	path = utils.ParsePath(path)
+	file, err := driver.File(path, account)
+	if err != nil {
+		return nil, err
+	}
+	if file.IsDir() {
+		return nil, base.ErrNotFile
+	}
+	return &base.Link{
+		Reader: func(offset int64) (io.ReadCloser, error) {
+			return readRange(path, offset, account)
+		},
+	}, nil
+}
+
+func (driver WebDav) Path(path string, account *model.Account) (*model.File, []model.File, error) {
+	log.Debugf("webdav path: %s", path)
+	file, err := driver.File(path, account)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !file.IsDir() {
+		return file, nil, nil
+	}
+	files, err := driver.Files(path, account)
+	if err != nil {
+		return nil, nil, err
+	}
+	model.SortFiles(files, account)
+	return nil, files, nil
+}
+
+func (driver WebDav) Proxy(c *gin.Context, account *model.Account) {
+
+}
+
+func (driver WebDav) Preview(path string, account *model.Account) (interface{}, error) {
+	return nil, base.ErrNotSupport
+}
+
+func (driver WebDav) Search(path string, keyword string, account *model.Account) ([]*model.File, error) {
+	return nil, base.ErrNotSupport
+}
+
+func (driver WebDav) MakeDir(path string, account *model.Account) error {
+	return do("MKCOL", path, account, nil, nil)
+}
+
+func (driver WebDav) Move(src string, dst string, account *model.Account) error {
+	return moveOrCopy("MOVE", src, dst, account)
+}
+
+func (driver WebDav) Copy(src string, dst string, account *model.Account) error {
+	return moveOrCopy("COPY", src, dst, account)
+}
+
+func (driver WebDav) Delete(path string, account *model.Account) error {
+	return do(http.MethodDelete, path, account, nil, nil)
+}
+
+func (driver WebDav) Upload(file *model.FileStream, account *model.Account) error {
+	path := utils.Join(file.ParentPath, file.Name)
+	req, err := http.NewRequest(http.MethodPut, getUrl(path, account), file)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(file.Size)
+	if account.Username != "" || account.Password != "" {
+		req.SetBasicAuth(account.Username, account.Password)
+	}
+	if file.MIMEType != "" {
+		req.Header.Set("Content-Type", file.MIMEType)
+	}
+	res, err := streamClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("PUT %s: %s", path, res.Status)
+	}
+	return nil
+}
+
+var _ base.Driver = (*WebDav)(nil)
@@ -0,0 +1,192 @@
+package webdav
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/Xhofe/alist/drivers/base"
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/utils"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// transport 连接和等待响应都有超时，传输数据没有总的超时，以便读写大文件
+var transport = &http.Transport{
+	Proxy:                 http.ProxyFromEnvironment,
+	DialContext:           (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ResponseHeaderTimeout: time.Minute,
+	IdleConnTimeout:       90 * time.Second,
+}
+
+// client 用于读写文件以外的请求
+var client = &http.Client{Transport: transport, Timeout: time.Minute}
+
+// streamClient 用于下载和上传文件
+var streamClient = &http.Client{Transport: transport}
+
+// getUrl get the url of the path in the remote server
+func getUrl(path string, account *model.Account) string {
+	realPath := utils.Join(account.RootFolder, path)
+	segments := strings.Split(realPath, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	return strings.TrimRight(account.SiteUrl, "/") + strings.Join(segments, "/")
+}
+
+// request send the WebDAV request, return error if the status is not 2xx
+func request(method string, path string, account *model.Account, headers map[string]string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, getUrl(path, account), body)
+	if err != nil {
+		return nil, err
+	}
+	if account.Username != "" || account.Password != "" {
+		req.SetBasicAuth(account.Username, account.Password)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	c := client
+	if method == http.MethodGet {
+		c = streamClient
+	}
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		_ = res.Body.Close()
+		if res.StatusCode == http.StatusNotFound {
+			return nil, base.ErrPathNotFound
+		}
+		return nil, fmt.Errorf("%s %s: %s", method, path, res.Status)
+	}
+	return res, nil
+}
+
+// do send the request and discard the body
+func do(method string, path string, account *model.Account, headers map[string]string, body io.Reader) error {
+	res, err := request(method, path, account, headers, body)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+	return res.Body.Close()
+}
+
+const propfindBody = `<?xml version="1.0" encoding="utf-8" ?>
+<D:propfind xmlns:D="DAV:">
+	<D:prop>
+		<D:resourcetype/>
+		<D:getcontentlength/>
+		<D:getlastmodified/>
+	</D:prop>
+</D:propfind>`
+
+type multistatus struct {
+	Responses []response `xml:"DAV: response"`
+}
+
+type response struct {
+	Href      string     `xml:"DAV: href"`
+	Propstats []propstat `xml:"DAV: propstat"`
+}
+
+type propstat struct {
+	Status string `xml:"DAV: status"`
+	Prop   struct {
+		ResourceType struct {
+			Collection *struct{} `xml:"DAV: collection"`
+		} `xml:"DAV: resourcetype"`
+		ContentLength int64  `xml:"DAV: getcontentlength"`
+		LastModified  string `xml:"DAV: getlastmodified"`
+	} `xml:"DAV: prop"`
+}
+
+// entry 一个PROPFIND结果
+type entry struct {
+	path    string // 远程服务器上的路径，不以/结尾
+	isDir   bool
+	size    int64
+	modTime *time.Time
+}
+
+// propfind list the entries in the folder with depth 1, the folder itself is included
+func propfind(path string, account *model.Account) ([]entry, error) {
+	res, err := request("PROPFIND", path, account, map[string]string{
+		"Depth":        "1",
+		"Content-Type": "application/xml; charset=utf-8",
+	}, strings.NewReader(propfindBody))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	var ms multistatus
+	if err = xml.NewDecoder(res.Body).Decode(&ms); err != nil {
+		return nil, err
+	}
+	entries := make([]entry, 0, len(ms.Responses))
+	for _, r := range ms.Responses {
+		u, err := url.Parse(r.Href)
+		if err != nil {
+			return nil, err
+		}
+		e := entry{path: strings.TrimRight(u.Path, "/")}
+		for _, ps := range r.Propstats {
+			if ps.Status != "" && !strings.Contains(ps.Status, " 200 ") {
+				continue
+			}
+			if ps.Prop.ResourceType.Collection != nil {
+				e.isDir = true
+			}
+			if ps.Prop.ContentLength > 0 {
+				e.size = ps.Prop.ContentLength
+			}
+			if t, err := http.ParseTime(ps.Prop.LastModified); err == nil {
+				e.modTime = &t
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// readRange read the file from the offset with the Range header
+func readRange(path string, offset int64, account *model.Account) (io.ReadCloser, error) {
+	headers := map[string]string{}
+	if offset > 0 {
+		headers["Range"] = fmt.Sprintf("bytes=%d-", offset)
+	}
+	res, err := request(http.MethodGet, path, account, headers, nil)
+	if err != nil {
+		return nil, err
+	}
+	// 服务器不支持Range时跳过前面的数据
+	if offset > 0 && res.StatusCode != http.StatusPartialContent {
+		if _, err = io.CopyN(ioutil.Discard, res.Body, offset); err != nil {
+			_ = res.Body.Close()
+			return nil, err
+		}
+	}
+	return res.Body, nil
+}
+
+// moveOrCopy send MOVE or COPY with the destination url
+func moveOrCopy(method string, src string, dst string, account *model.Account) error {
+	return do(method, src, account, map[string]string{
+		"Destination": getUrl(dst, account),
+		"Overwrite":   "F",
+	}, nil)
+}
+
+func init() {
+	base.RegisterDriver(&WebDav{})
+}
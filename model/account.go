@@ -1,14 +1,22 @@
 package model
 
 import (
+	"errors"
+	"fmt"
 	"github.com/Xhofe/alist/conf"
+	"github.com/Xhofe/alist/utils"
 	"github.com/robfig/cron/v3"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Account struct {
 	ID             uint   `json:"id" gorm:"primaryKey"`                  // 唯一ID
 	Name           string `json:"name" gorm:"unique" binding:"required"` // 唯一名称
+	MountPath      string `json:"mount_path"`                            // 挂载路径
 	Index          int    `json:"index"`                                 // 序号 用于排序
 	Type           string `json:"type"`                                  // 类型，即driver
 	Username       string `json:"username"`
@@ -35,17 +43,86 @@ type Account struct {
 	Proxy          bool       `json:"proxy"`       // 是否中转
 	//AllowProxy     bool       `json:"allow_proxy"` // 是否允许中转下载
 	ProxyUrl       string     `json:"proxy_url"`   // 用于中转下载服务的URL
+	MaxConnections int        `json:"max_connections"` // 连接池的最大连接数
+	IdleTimeout    int        `json:"idle_timeout"`    // 连接池中空闲连接保留的时间，单位秒
+	TlsMode        string     `json:"tls_mode"`        // none,explicit,implicit
+	TlsInsecure    bool       `json:"tls_insecure"`    // 跳过证书验证
+	CaFile         string     `json:"ca_file"`         // 用于验证证书的CA文件
+	PrivateKey     string     `json:"private_key"`     // ssh私钥
+	HostKey        string     `json:"host_key"`        // ssh主机公钥，为空时信任首次连接的主机
+	Endpoint       string     `json:"endpoint"`
+	Region         string     `json:"region"`
+	Bucket         string     `json:"bucket"`
+	AccessKey      string     `json:"access_key"`
+	AccessSecret   string     `json:"access_secret"`
+	PathStyle      bool       `json:"path_style"`
+	LinkExpire     int        `json:"link_expire"` // s3预签名链接的有效期，单位分钟
+	Share          string     `json:"share"`  // smb共享名
+	Domain         string     `json:"domain"` // smb域
+	Members        string     `json:"members"`      // union合并的路径，逗号分隔，靠前的优先
+	WriteTarget    string     `json:"write_target"` // union写入的路径
+	CacheTime      int        `json:"cache_time"`   // 目录缓存时间，单位分钟，0则使用配置文件中的值
+	NoCache        bool       `json:"-" gorm:"-"`   // 刷新缓存时的读取不使用缓存，不会被注册
 }
 
-var accountsMap = map[string]Account{}
+// accounts 已注册的账号，写时复制：每次修改都复制一份新的map后整体替换，
+// 读取时拿到的快照不会再被修改，不需要加锁
+var accounts atomic.Value // map[string]Account
+var accountsLock sync.Mutex // 串行化写操作
 
-// SaveAccount save account to database
-func SaveAccount(account *Account) error {
-	if err := conf.DB.Save(account).Error; err != nil {
-		return err
+func init() {
+	accounts.Store(map[string]Account{})
+}
+
+// snapshot get the current registered accounts, the map must not be modified
+func snapshot() map[string]Account {
+	return accounts.Load().(map[string]Account)
+}
+
+// updateAccounts modify a copy of the registered accounts and swap it in
+func updateAccounts(fn func(m map[string]Account)) {
+	accountsLock.Lock()
+	defer accountsLock.Unlock()
+	old := snapshot()
+	m := make(map[string]Account, len(old)+1)
+	for k, v := range old {
+		m[k] = v
 	}
-	RegisterAccount(*account)
-	return nil
+	fn(m)
+	accounts.Store(m)
+}
+
+// SaveAccount save account to database, the database and the registered accounts are updated under the same lock
+func SaveAccount(account *Account) error {
+	var err error
+	updateAccounts(func(m map[string]Account) {
+		if err = conf.DB.Save(account).Error; err == nil {
+			registerAccount(m, *account)
+		}
+	})
+	return err
+}
+
+var ErrAccountNotFound = errors.New("account not found")
+
+// UpdateAccountFields update the columns of the registered account by fn. fn is applied to the latest
+// account under the lock and only the columns are saved, so the other changes are not overwritten.
+// ErrAccountNotFound is returned if the account has been deleted.
+func UpdateAccountFields(id uint, fn func(account *Account), columns ...string) error {
+	err := ErrAccountNotFound
+	updateAccounts(func(m map[string]Account) {
+		for name, account := range m {
+			if account.ID != id {
+				continue
+			}
+			fn(&account)
+			if err = conf.DB.Model(&account).Select(columns).Updates(&account).Error; err == nil {
+				m[name] = account
+			}
+			return
+		}
+	})
+	return err
 }
 
 func CreateAccount(account *Account) error {
@@ -67,56 +144,170 @@ func DeleteAccount(id uint) error {
 	if err := conf.DB.Delete(&account).Error; err != nil {
 		return err
 	}
-	delete(accountsMap, name)
+	_ = ClearSearchFiles(id)
+	UnregisterAccount(name)
 	return nil
 }
 
-func DeleteAccountFromMap(name string) {
-	delete(accountsMap, name)
+// UnregisterAccount remove the account from the registered accounts
+func UnregisterAccount(name string) {
+	updateAccounts(func(m map[string]Account) {
+		delete(m, name)
+	})
 }
 
 func AccountsCount() int {
-	return len(accountsMap)
+	return len(snapshot())
 }
 
+// RegisterAccount add or replace the account, the old name of the same account is removed at the same time
 func RegisterAccount(account Account) {
-	accountsMap[account.Name] = account
+	updateAccounts(func(m map[string]Account) {
+		registerAccount(m, account)
+	})
 }
 
-func GetAccount(name string) (Account, bool) {
-	if len(accountsMap) == 1 {
-		for _, v := range accountsMap {
-			return v, true
+func registerAccount(m map[string]Account, account Account) {
+	account.NoCache = false
+	for k, v := range m {
+		if v.ID == account.ID && k != account.Name {
+			delete(m, k)
 		}
 	}
-	account, ok := accountsMap[name]
-	return account, ok
+	m[account.Name] = account
 }
 
-func GetAccountById(id uint) (*Account, error) {
+// RefreshAccountToken run refresh on a copy of the latest registered account, then save only the tokens and
+// the status, so the changes made during the refresh are kept. ErrAccountNotFound is returned if it's deleted.
+func RefreshAccountToken(id uint, refresh func(account *Account) error) error {
 	var account Account
-	account.ID = id
-	if err := conf.DB.First(&account).Error; err != nil {
-		return nil, err
+	found := false
+	for _, v := range snapshot() {
+		if v.ID == id {
+			account, found = v, true
+			break
+		}
 	}
-	return &account, nil
+	if !found {
+		return ErrAccountNotFound
+	}
+	err := refresh(&account)
+	updateErr := UpdateAccountFields(id, func(a *Account) {
+		a.AccessToken, a.RefreshToken, a.Status = account.AccessToken, account.RefreshToken, account.Status
+	}, "access_token", "refresh_token", "status")
+	if err != nil {
+		return err
+	}
+	return updateErr
 }
 
-func GetAccountFiles() ([]File, error) {
-	files := make([]File, 0)
-	var accounts []Account
-	if err := conf.DB.Order("`index`").Find(&accounts).Error; err != nil {
-		return nil, err
+func GetAccount(name string) (Account, bool) {
+	account, ok := snapshot()[name]
+	return account, ok
+}
+
+// isUnder whether the path is the prefix or under the prefix
+func isUnder(path string, prefix string) bool {
+	return prefix == "/" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// sortedAccounts get the registered accounts sorted by index
+func sortedAccounts() []Account {
+	m := snapshot()
+	res := make([]Account, 0, len(m))
+	for _, v := range m {
+		res = append(res, v)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Index != res[j].Index {
+			return res[i].Index < res[j].Index
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// GetAccountByPath get the account mounted at the longest prefix of the path, and the path in the account
+func GetAccountByPath(path string) (Account, string, bool) {
+	path = utils.ParsePath(path)
+	var res Account
+	found := false
+	for _, v := range snapshot() {
+		if !isUnder(path, v.MountPath) {
+			continue
+		}
+		if !found || len(v.MountPath) > len(res.MountPath) {
+			res = v
+			found = true
+		}
 	}
-	for _, v := range accounts {
+	if !found {
+		return res, "", false
+	}
+	return res, utils.ParsePath(strings.TrimPrefix(path, strings.TrimRight(res.MountPath, "/"))), true
+}
+
+// GetAccountsUnder get the accounts mounted at or under the path
+func GetAccountsUnder(path string) []Account {
+	path = utils.ParsePath(path)
+	res := make([]Account, 0)
+	for _, v := range sortedAccounts() {
+		if isUnder(v.MountPath, path) {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// GetVirtualFiles get the virtual folders in the path leading to the accounts mounted under it
+func GetVirtualFiles(path string) []File {
+	path = utils.ParsePath(path)
+	files := make([]File, 0)
+	names := make(map[string]bool)
+	for _, v := range sortedAccounts() {
+		if v.MountPath == path || !isUnder(v.MountPath, path) {
+			continue
+		}
+		name := strings.SplitN(strings.TrimPrefix(strings.TrimPrefix(v.MountPath, path), "/"), "/", 2)[0]
+		if names[name] {
+			continue
+		}
+		names[name] = true
 		files = append(files, File{
-			Name:      v.Name,
+			Name:      name,
 			Size:      0,
 			Type:      conf.FOLDER,
+			Driver:    "root",
 			UpdatedAt: v.UpdatedAt,
 		})
 	}
-	return files, nil
+	return files
+}
+
+// CheckMountPath fix the mount path of the account, which is /name by default and can't be used by other accounts.
+// Nested mount paths (e.g. /a and /a/b) are allowed: a path belongs to the account with the longest mount path
+// containing it (see GetAccountByPath), so /a/b and everything under it is served by the account at /a/b,
+// and the folder b of the account at /a, if any, is hidden.
+func CheckMountPath(account *Account) error {
+	if account.MountPath == "" {
+		account.MountPath = "/" + account.Name
+	}
+	account.MountPath = utils.ParsePath(account.MountPath)
+	for _, v := range snapshot() {
+		if v.ID != account.ID && v.MountPath == account.MountPath {
+			return fmt.Errorf("[%s] is already mounted by account [%s]", account.MountPath, v.Name)
+		}
+	}
+	return nil
+}
+
+func GetAccountById(id uint) (*Account, error) {
+	var account Account
+	account.ID = id
+	if err := conf.DB.First(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
 }
 
 func GetAccounts() ([]Account, error) {
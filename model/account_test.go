@@ -0,0 +1,155 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/Xhofe/alist/conf"
+	"github.com/Xhofe/alist/utils/testdb"
+	"github.com/robfig/cron/v3"
+)
+
+func setAccounts(t *testing.T, mountPaths ...string) {
+	m := make(map[string]Account, len(mountPaths))
+	for i, mountPath := range mountPaths {
+		account := Account{ID: uint(i + 1), Name: mountPath, MountPath: mountPath}
+		m[account.Name] = account
+	}
+	accounts.Store(m)
+	t.Cleanup(func() {
+		accounts.Store(map[string]Account{})
+	})
+}
+
+func TestGetAccountByPath(t *testing.T) {
+	setAccounts(t, "/a", "/a/b", "/ab", "/c/d")
+	tests := []struct {
+		path      string
+		mountPath string
+		subPath   string
+		ok        bool
+	}{
+		{"/a", "/a", "/", true},
+		{"/a/", "/a", "/", true},
+		{"/a/x/y", "/a", "/x/y", true},
+		{"/a/b", "/a/b", "/", true},
+		{"/a/b/x", "/a/b", "/x", true},
+		{"/a/bc", "/a", "/bc", true},
+		{"/ab/x", "/ab", "/x", true},
+		{"/c/d/x", "/c/d", "/x", true},
+		{"/c", "", "", false},
+		{"/", "", "", false},
+		{"/x", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			account, subPath, ok := GetAccountByPath(tt.path)
+			if ok != tt.ok || account.MountPath != tt.mountPath || subPath != tt.subPath {
+				t.Errorf("GetAccountByPath(%q) = %q, %q, %v, want %q, %q, %v",
+					tt.path, account.MountPath, subPath, ok, tt.mountPath, tt.subPath, tt.ok)
+			}
+		})
+	}
+}
+
+func TestGetAccountByPathRoot(t *testing.T) {
+	setAccounts(t, "/", "/a")
+	tests := []struct {
+		path      string
+		mountPath string
+		subPath   string
+	}{
+		{"/", "/", "/"},
+		{"/x/y", "/", "/x/y"},
+		{"/a/x", "/a", "/x"},
+	}
+	for _, tt := range tests {
+		account, subPath, ok := GetAccountByPath(tt.path)
+		if !ok || account.MountPath != tt.mountPath || subPath != tt.subPath {
+			t.Errorf("GetAccountByPath(%q) = %q, %q, %v, want %q, %q",
+				tt.path, account.MountPath, subPath, ok, tt.mountPath, tt.subPath)
+		}
+	}
+}
+
+func TestCheckMountPath(t *testing.T) {
+	setAccounts(t, "/a", "/a/b")
+	tests := []struct {
+		name      string
+		account   Account
+		mountPath string
+		ok        bool
+	}{
+		{"default", Account{ID: 10, Name: "x"}, "/x", true},
+		{"parsed", Account{ID: 10, Name: "x", MountPath: "y/z/"}, "/y/z", true},
+		{"nested", Account{ID: 10, Name: "x", MountPath: "/a/b/c"}, "/a/b/c", true},
+		{"parent", Account{ID: 10, Name: "x", MountPath: "/"}, "/", true},
+		{"used", Account{ID: 10, Name: "x", MountPath: "/a/b/"}, "/a/b", false},
+		{"used by default", Account{ID: 10, Name: "a"}, "/a", false},
+		{"itself", Account{ID: 1, Name: "x", MountPath: "/a"}, "/a", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := tt.account
+			err := CheckMountPath(&account)
+			if (err == nil) != tt.ok || account.MountPath != tt.mountPath {
+				t.Errorf("CheckMountPath(%+v) = %v, mount path %q, want ok %v, %q",
+					tt.account, err, account.MountPath, tt.ok, tt.mountPath)
+			}
+		})
+	}
+}
+
+func initAccountDB(t *testing.T) {
+	testdb.Init(t, &Account{})
+	conf.Cron = cron.New()
+	t.Cleanup(func() {
+		accounts.Store(map[string]Account{})
+	})
+}
+
+// 刷新token期间修改的账号信息不会被覆盖，删除的账号不会被重新创建
+func TestRefreshAccountToken(t *testing.T) {
+	initAccountDB(t)
+	account := Account{Name: "a", RefreshToken: "r0"}
+	if err := CreateAccount(&account); err != nil {
+		t.Fatal(err)
+	}
+	err := RefreshAccountToken(account.ID, func(a *Account) error {
+		edited := account
+		edited.Name, edited.RootFolder = "b", "/edited"
+		if err := SaveAccount(&edited); err != nil {
+			return err
+		}
+		a.AccessToken, a.RefreshToken, a.Status = "a1", "r1", "work"
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := GetAccountById(account.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registered, ok := GetAccount("b")
+	for _, a := range []Account{*saved, registered} {
+		if a.Name != "b" || a.RootFolder != "/edited" || a.AccessToken != "a1" || a.RefreshToken != "r1" || a.Status != "work" {
+			t.Errorf("unexpected account: %+v", a)
+		}
+	}
+	if _, ok = GetAccount("a"); ok || AccountsCount() != 1 {
+		t.Errorf("the old name is still registered")
+	}
+	err = RefreshAccountToken(account.ID, func(a *Account) error {
+		if err := DeleteAccount(a.ID); err != nil {
+			return err
+		}
+		a.AccessToken = "a2"
+		return nil
+	})
+	if err != ErrAccountNotFound {
+		t.Errorf("refresh the deleted account: %v", err)
+	}
+	if _, err = GetAccountById(account.ID); err == nil {
+		t.Errorf("the deleted account is created again")
+	}
+}
@@ -18,6 +18,7 @@ type File struct {
 	Url       string     `json:"url"`
 	SizeStr   string     `json:"size_str"`
 	TimeStr   string     `json:"time_str"`
+	Path      string     `json:"path,omitempty"` // 所在目录,仅搜索结果使用
 }
 
 func SortFiles(files []File, account *Account) {
@@ -1,6 +1,9 @@
 package model
 
-import "io"
+import (
+	"context"
+	"io"
+)
 
 type FileStream struct {
 	File       io.ReadCloser
@@ -8,6 +11,15 @@ type FileStream struct {
 	ParentPath string
 	Name       string
 	MIMEType   string
+	Ctx        context.Context // 上传所在的请求或任务的ctx，可以为空
+}
+
+// Context get the ctx of the upload, never nil
+func (file FileStream) Context() context.Context {
+	if file.Ctx == nil {
+		return context.Background()
+	}
+	return file.Ctx
 }
 
 func (file FileStream) Read(p []byte) (n int, err error) {
@@ -2,6 +2,7 @@ package model
 
 import (
 	"github.com/Xhofe/alist/conf"
+	"github.com/Xhofe/alist/utils"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -10,6 +11,12 @@ type Meta struct {
 	Path     string `json:"path" gorm:"unique" binding:"required"`
 	Password string `json:"password"`
 	Hide     string `json:"hide"`
+	// 权限，为空时继承上级目录
+	AllowUpload      *bool `json:"allow_upload"`
+	AllowMkdir       *bool `json:"allow_mkdir"`
+	AllowModify      *bool `json:"allow_modify"` // 删除、重命名、移动
+	AllowWebdavWrite *bool `json:"allow_webdav_write"`
+	NoDirectDown     *bool `json:"no_direct_down"`
 }
 
 func GetMetaByPath(path string) (*Meta, error) {
@@ -42,3 +49,88 @@ func GetMetas() (*[]Meta, error) {
 	}
 	return &metas, nil
 }
+
+// GetMetaFlag find the flag in the metas of the path and its parents, like CheckParent.
+// The metas of all the parents are loaded in one query.
+func GetMetaFlag(path string, flag func(meta *Meta) *bool) *bool {
+	path = utils.ParsePath(path)
+	paths := []string{path}
+	for p := path; p != "/"; {
+		p = utils.Dir(p)
+		paths = append(paths, p)
+	}
+	var metas []Meta
+	if err := conf.DB.Where("path IN ?", paths).Find(&metas).Error; err != nil {
+		log.Errorf("failed to get metas of %s: %s", path, err.Error())
+		return nil
+	}
+	byPath := make(map[string]*Meta, len(metas))
+	for i := range metas {
+		byPath[metas[i].Path] = &metas[i]
+	}
+	for _, p := range paths {
+		if meta, ok := byPath[p]; ok {
+			if v := flag(meta); v != nil {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+func metaFlagOr(path string, flag func(meta *Meta) *bool, def bool) bool {
+	v := GetMetaFlag(path, flag)
+	if v == nil {
+		return def
+	}
+	return *v
+}
+
+// CanUpload check whether the user can upload files to the folder
+func CanUpload(user *User, path string) bool {
+	if user.IsAdmin() {
+		return true
+	}
+	if user.Role == GUEST {
+		return false
+	}
+	return metaFlagOr(path, func(meta *Meta) *bool { return meta.AllowUpload }, user.CanUpload())
+}
+
+// CanMkdir check whether the user can make folders in the folder
+func CanMkdir(user *User, path string) bool {
+	if user.IsAdmin() {
+		return true
+	}
+	if user.Role == GUEST {
+		return false
+	}
+	return metaFlagOr(path, func(meta *Meta) *bool { return meta.AllowMkdir }, user.CanUpload())
+}
+
+// CanModify check whether the user can delete, rename or move the path
+func CanModify(user *User, path string) bool {
+	if user.IsAdmin() {
+		return true
+	}
+	if user.Role == GUEST {
+		return false
+	}
+	return metaFlagOr(path, func(meta *Meta) *bool { return meta.AllowModify }, false)
+}
+
+// CanWebdavWrite check whether the path can be written through WebDAV
+func CanWebdavWrite(user *User, path string) bool {
+	if user.IsAdmin() {
+		return true
+	}
+	return metaFlagOr(path, func(meta *Meta) *bool { return meta.AllowWebdavWrite }, true)
+}
+
+// CanDown check whether the user can download the file directly, user is nil for anonymous
+func CanDown(user *User, path string) bool {
+	if user != nil && user.IsAdmin() {
+		return true
+	}
+	return !metaFlagOr(path, func(meta *Meta) *bool { return meta.NoDirectDown }, false)
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"github.com/Xhofe/alist/conf"
+	"strings"
+	"time"
+)
+
+// SearchFile 搜索索引中的一条记录
+type SearchFile struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	AccountId uint       `json:"account_id" gorm:"index"`
+	Path      string     `json:"path" gorm:"index"` // 所在目录
+	Name      string     `json:"name" gorm:"index"`
+	Size      int64      `json:"size"`
+	Type      int        `json:"type"`
+	Modified  *time.Time `json:"updated_at"` // 不使用UpdatedAt,避免被gorm自动更新
+	// 重建索引时写入的批次，重建完成后删除旧批次的记录，重建期间旧的索引仍然可以搜索
+	Generation int64 `json:"-" gorm:"index"`
+}
+
+// escapeLike escape the wildcards of LIKE, used with ESCAPE '\'
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (f SearchFile) File(driver string) File {
+	return File{
+		Name:      f.Name,
+		Size:      f.Size,
+		Type:      f.Type,
+		Driver:    driver,
+		UpdatedAt: f.Modified,
+		Path:      f.Path,
+	}
+}
+
+// GetSearchFiles get the index records directly under the path
+func GetSearchFiles(accountId uint, path string) ([]SearchFile, error) {
+	var files []SearchFile
+	if err := conf.DB.Where("account_id = ? AND path = ?", accountId, path).Find(&files).Error; err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func SaveSearchFiles(files []SearchFile) error {
+	if len(files) == 0 {
+		return nil
+	}
+	return conf.DB.Save(&files).Error
+}
+
+func DeleteSearchFile(file SearchFile) error {
+	return conf.DB.Delete(&file).Error
+}
+
+// DeleteSearchFilesUnder delete all the index records in the path recursively
+func DeleteSearchFilesUnder(accountId uint, path string) error {
+	return conf.DB.Where("account_id = ? AND (path = ? OR path LIKE ? ESCAPE ?)", accountId, path, escapeLike(path)+"/%", `\`).
+		Delete(&SearchFile{}).Error
+}
+
+// DeleteSearchFilesBefore delete the index records not written by the rebuild of the generation
+func DeleteSearchFilesBefore(accountId uint, generation int64) error {
+	return conf.DB.Where("account_id = ? AND generation < ?", accountId, generation).Delete(&SearchFile{}).Error
+}
+
+func ClearSearchFiles(accountId uint) error {
+	return conf.DB.Where("account_id = ?", accountId).Delete(&SearchFile{}).Error
+}
+
+func IsIndexed(accountId uint) bool {
+	var count int64
+	conf.DB.Model(&SearchFile{}).Where("account_id = ?", accountId).Count(&count)
+	return count > 0
+}
+
+// SearchIndex search the index records by keyword in the path recursively
+func SearchIndex(accountId uint, path string, keyword string) ([]SearchFile, error) {
+	var files []SearchFile
+	db := conf.DB.Where("account_id = ? AND name LIKE ? ESCAPE ?", accountId, "%"+escapeLike(keyword)+"%", `\`)
+	if path != "/" {
+		db = db.Where("(path = ? OR path LIKE ? ESCAPE ?)", path, escapeLike(path)+"/%", `\`)
+	}
+	if err := db.Find(&files).Error; err != nil {
+		return nil, err
+	}
+	return files, nil
+}
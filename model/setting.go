@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"github.com/Xhofe/alist/conf"
 	"github.com/Xhofe/alist/utils"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -98,6 +100,16 @@ func LoadSettings() {
 		conf.Token = utils.GetMD5Encode(fmt.Sprintf("https://github.com/Xhofe/alist-%s", adminPassword.Value))
 	}
 
+	signExpire, err := GetSettingByKey("sign expire")
+	if err == nil {
+		hours, _ := strconv.Atoi(signExpire.Value)
+		conf.SignExpire = time.Duration(hours) * time.Hour
+	}
+	legacySign, err := GetSettingByKey("legacy sign")
+	if err == nil {
+		conf.LegacySign = legacySign.Value == "true"
+	}
+
 	davUsername, err := GetSettingByKey("WebDAV username")
 	if err == nil {
 		conf.DavUsername = davUsername.Value
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"github.com/Xhofe/alist/conf"
+	"github.com/Xhofe/alist/utils"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"time"
+)
+
+type Share struct {
+	ID           string     `json:"id" gorm:"primaryKey"`
+	Path         string     `json:"path" binding:"required"`
+	Password     string     `json:"password,omitempty" gorm:"-"` // 明文密码,仅用于创建
+	PwdHash      string     `json:"-"`
+	HasPassword  bool       `json:"has_password" gorm:"-"`
+	ExpiresAt    *time.Time `json:"expires_at"`    // 为空则永不过期
+	MaxDownloads int        `json:"max_downloads"` // 为0则不限制
+	Downloads    int        `json:"downloads"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ShareLog 分享的访问记录
+type ShareLog struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ShareId   string    `json:"share_id" gorm:"index"`
+	Path      string    `json:"path"`
+	Ip        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	Download  bool      `json:"download"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (s Share) Expired() bool {
+	return s.ExpiresAt != nil && s.ExpiresAt.Before(time.Now())
+}
+
+// CheckPassword check the password of the share, always true if the share has no password
+func (s Share) CheckPassword(password string) bool {
+	if s.PwdHash == "" {
+		return true
+	}
+	return bcrypt.CompareHashAndPassword([]byte(s.PwdHash), []byte(password)) == nil
+}
+
+func CreateShare(share *Share) error {
+	share.ID = utils.RandomStr(8)
+	share.Downloads = 0
+	share.Path = utils.ParsePath(share.Path)
+	share.PwdHash = ""
+	if share.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(share.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		share.PwdHash = string(hash)
+	}
+	share.Password = ""
+	share.HasPassword = share.PwdHash != ""
+	return conf.DB.Create(share).Error
+}
+
+func GetShareById(id string) (*Share, error) {
+	var share Share
+	if err := conf.DB.Where("id = ?", id).First(&share).Error; err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+func GetShares() ([]Share, error) {
+	var shares []Share
+	if err := conf.DB.Order("created_at desc").Find(&shares).Error; err != nil {
+		return nil, err
+	}
+	for i := range shares {
+		shares[i].HasPassword = shares[i].PwdHash != ""
+	}
+	return shares, nil
+}
+
+func DeleteShare(id string) error {
+	if err := conf.DB.Where("share_id = ?", id).Delete(&ShareLog{}).Error; err != nil {
+		return err
+	}
+	return conf.DB.Where("id = ?", id).Delete(&Share{}).Error
+}
+
+// IncreaseShareDownloads increase the downloads of the share, return false if reach the max downloads
+func IncreaseShareDownloads(id string) (bool, error) {
+	res := conf.DB.Model(&Share{}).
+		Where("id = ? AND (max_downloads = 0 OR downloads < max_downloads)", id).
+		UpdateColumn("downloads", gorm.Expr("downloads + 1"))
+	return res.RowsAffected > 0, res.Error
+}
+
+func CreateShareLog(log *ShareLog) error {
+	return conf.DB.Create(log).Error
+}
+
+func GetShareLogs(id string) ([]ShareLog, error) {
+	var logs []ShareLog
+	if err := conf.DB.Where("share_id = ?", id).Order("created_at desc").Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"github.com/Xhofe/alist/conf"
+	"time"
+)
+
+// task types
+const (
+	TaskUpload = "upload"
+	TaskCopy   = "copy"
+	TaskMove   = "move"
+	TaskDelete = "delete"
+	// TaskDownload 离线下载, SrcPath 为下载的url
+	TaskDownload = "download"
+)
+
+// task status
+const (
+	TaskPending   = "pending"
+	TaskRunning   = "running"
+	TaskSucceeded = "succeeded"
+	TaskFailed    = "failed"
+	TaskCancelled = "cancelled"
+)
+
+// Task 后台任务,保存在数据库中以便重启后继续
+type Task struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Type       string    `json:"type"`
+	SrcAccount string    `json:"src_account"`
+	SrcPath    string    `json:"src_path"` // 上传任务为临时文件的路径,离线下载为url
+	DstAccount string    `json:"dst_account"`
+	DstPath    string    `json:"dst_path"`
+	Status     string    `json:"status" gorm:"index"`
+	Error      string    `json:"error"`
+	Done       int64     `json:"done"`  // 已传输的字节数
+	Total      int64     `json:"total"` // 总字节数,未知时为0
+	Retries    int       `json:"retries"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (t Task) Finished() bool {
+	return t.Status == TaskSucceeded || t.Status == TaskFailed || t.Status == TaskCancelled
+}
+
+func CreateTask(task *Task) error {
+	return conf.DB.Create(task).Error
+}
+
+func SaveTask(task *Task) error {
+	return conf.DB.Save(task).Error
+}
+
+func DeleteTask(id uint) error {
+	task := Task{ID: id}
+	return conf.DB.Delete(&task).Error
+}
+
+func GetTasks() ([]Task, error) {
+	var tasks []Task
+	if err := conf.DB.Order("id desc").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func GetTaskById(id uint) (*Task, error) {
+	var task Task
+	task.ID = id
+	if err := conf.DB.First(&task).Error; err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// GetUnfinishedTasks get the tasks pending or interrupted while running
+func GetUnfinishedTasks() ([]Task, error) {
+	var tasks []Task
+	if err := conf.DB.Where("status IN ?", []string{TaskPending, TaskRunning}).Order("id").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
@@ -0,0 +1,195 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/Xhofe/alist/conf"
+	"github.com/Xhofe/alist/utils"
+	"golang.org/x/crypto/bcrypt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// user roles
+const (
+	GUEST = iota
+	UPLOADER
+	ADMIN
+)
+
+type User struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Username string `json:"username" gorm:"unique" binding:"required"`
+	Password string `json:"password" gorm:"-"` // 明文密码,仅用于创建和修改
+	PwdHash  string `json:"-"`
+	Token    string `json:"-" gorm:"index"`
+	Role     int    `json:"role"`
+	BasePath string `json:"base_path"` // 用户能看到的根目录
+}
+
+// AdminUser the user of the admin password and the WebDAV username/password in settings
+var AdminUser = User{
+	Username: "admin",
+	Role:     ADMIN,
+	BasePath: "/",
+}
+
+func (u User) IsAdmin() bool {
+	return u.Role == ADMIN
+}
+
+func (u User) CanUpload() bool {
+	return u.Role >= UPLOADER
+}
+
+// RealPath convert the path seen by the user to the real path
+func (u User) RealPath(p string) string {
+	return utils.ParsePath(utils.Join(u.BasePath, path.Clean("/"+p)))
+}
+
+// ViewPath convert the real path to the path seen by the user, the reverse of RealPath
+func (u User) ViewPath(p string) string {
+	return utils.ParsePath(strings.TrimPrefix(p, strings.TrimRight(u.BasePath, "/")))
+}
+
+func (u *User) setPassword(password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u.PwdHash = string(hash)
+	u.Token = utils.GetMD5Encode(fmt.Sprintf("alist-%s-%s-%d", u.Username, u.PwdHash, time.Now().UnixNano()))
+	return nil
+}
+
+func (u *User) CheckPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(u.PwdHash), []byte(password)) == nil
+}
+
+func CreateUser(user *User) error {
+	if user.Password == "" {
+		return errors.New("password is required")
+	}
+	if err := user.setPassword(user.Password); err != nil {
+		return err
+	}
+	user.BasePath = utils.ParsePath(user.BasePath)
+	return conf.DB.Create(user).Error
+}
+
+// SaveUser save the user, keep the old password if not set, create the user if the id is not set
+func SaveUser(user *User) error {
+	if user.ID == 0 {
+		return CreateUser(user)
+	}
+	old, err := GetUserById(user.ID)
+	if err != nil {
+		return err
+	}
+	if user.Password != "" {
+		if err = user.setPassword(user.Password); err != nil {
+			return err
+		}
+	} else {
+		user.PwdHash = old.PwdHash
+		user.Token = old.Token
+	}
+	user.BasePath = utils.ParsePath(user.BasePath)
+	if err = conf.DB.Save(user).Error; err != nil {
+		return err
+	}
+	clearCheckedUsers()
+	return nil
+}
+
+func DeleteUser(id uint) error {
+	user := User{ID: id}
+	if err := conf.DB.Delete(&user).Error; err != nil {
+		return err
+	}
+	clearCheckedUsers()
+	return nil
+}
+
+func GetUsers() ([]User, error) {
+	var users []User
+	if err := conf.DB.Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func GetUserById(id uint) (*User, error) {
+	var user User
+	user.ID = id
+	if err := conf.DB.First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func GetUserByToken(token string) (*User, error) {
+	var user User
+	if err := conf.DB.Where("token = ?", token).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CheckUser check the username and password, return the user if correct
+func CheckUser(username string, password string) (*User, error) {
+	var user User
+	if err := conf.DB.Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, err
+	}
+	if !user.CheckPassword(password) {
+		return nil, errors.New("wrong password")
+	}
+	return &user, nil
+}
+
+// CheckedUserTime 验证通过的用户名密码缓存的时间，WebDAV每个请求都要验证，避免每次都计算bcrypt
+const CheckedUserTime = time.Minute
+
+type checkedUser struct {
+	user   User
+	expire time.Time
+}
+
+var checkedUsers = map[string]checkedUser{}
+var checkedUsersLock sync.Mutex
+
+func checkedUserKey(username string, password string) string {
+	sum := sha256.Sum256([]byte(username + "\x00" + password))
+	return hex.EncodeToString(sum[:])
+}
+
+func clearCheckedUsers() {
+	checkedUsersLock.Lock()
+	checkedUsers = map[string]checkedUser{}
+	checkedUsersLock.Unlock()
+}
+
+// CheckUserCached like CheckUser, but the successful result is cached for CheckedUserTime
+func CheckUserCached(username string, password string) (*User, error) {
+	key := checkedUserKey(username, password)
+	checkedUsersLock.Lock()
+	v, ok := checkedUsers[key]
+	checkedUsersLock.Unlock()
+	if ok && time.Now().Before(v.expire) {
+		user := v.user
+		return &user, nil
+	}
+	user, err := CheckUser(username, password)
+	if err != nil {
+		return nil, err
+	}
+	checkedUsersLock.Lock()
+	checkedUsers[key] = checkedUser{user: *user, expire: time.Now().Add(CheckedUserTime)}
+	checkedUsersLock.Unlock()
+	return user, nil
+}
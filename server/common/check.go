@@ -6,6 +6,7 @@ import (
 	"github.com/Xhofe/alist/utils"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
+	"strings"
 )
 
 func Login(c *gin.Context) {
@@ -27,6 +28,18 @@ func CheckParent(path string, password string) bool {
 	}
 }
 
+// CheckPathPassword check the password of the path, and of its parents if CheckParent is enabled
+func CheckPathPassword(path string, password string) bool {
+	meta, err := model.GetMetaByPath(path)
+	if err == nil {
+		return meta.Password == "" || meta.Password == password
+	}
+	if conf.CheckParent {
+		return CheckParent(utils.Dir(path), password)
+	}
+	return true
+}
+
 func CheckDownLink(path string, passwordMd5 string, name string) bool {
 	if !conf.CheckDown {
 		return true
@@ -49,3 +62,28 @@ func CheckDownLink(path string, passwordMd5 string, name string) bool {
 		return CheckDownLink(utils.Dir(path), passwordMd5, name)
 	}
 }
+
+// IsHidden check whether the file is hidden by the meta of its parent folder
+func IsHidden(path string) bool {
+	if path == "/" {
+		return false
+	}
+	meta, _ := model.GetMetaByPath(utils.Dir(path))
+	return meta != nil && meta.Hide != "" && utils.IsContain(strings.Split(meta.Hide, ","), utils.Base(path))
+}
+
+// HideFiles remove the files hidden by the meta of the folder
+func HideFiles(path string, files []model.File) []model.File {
+	meta, _ := model.GetMetaByPath(path)
+	if meta == nil || meta.Hide == "" {
+		return files
+	}
+	tmpFiles := make([]model.File, 0)
+	hideFiles := strings.Split(meta.Hide, ",")
+	for _, item := range files {
+		if !utils.IsContain(hideFiles, item.Name) {
+			tmpFiles = append(tmpFiles, item)
+		}
+	}
+	return tmpFiles
+}
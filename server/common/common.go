@@ -4,9 +4,9 @@ import (
 	"fmt"
 	"github.com/Xhofe/alist/drivers/base"
 	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/utils"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
-	"strings"
 )
 
 type Resp struct {
@@ -20,22 +20,14 @@ type PathReq struct {
 	Password string `json:"password"`
 }
 
+// ParsePath get the account mounted at the longest prefix of the path, with its driver and the path in the account
 func ParsePath(rawPath string) (*model.Account, string, base.Driver, error) {
-	var path, name string
-	switch model.AccountsCount() {
-	case 0:
+	if model.AccountsCount() == 0 {
 		return nil, "", nil, fmt.Errorf("no accounts,please add one first")
-	case 1:
-		path = rawPath
-		break
-	default:
-		paths := strings.Split(rawPath, "/")
-		path = "/" + strings.Join(paths[2:], "/")
-		name = paths[1]
 	}
-	account, ok := model.GetAccount(name)
+	account, path, ok := model.GetAccountByPath(rawPath)
 	if !ok {
-		return nil, "", nil, fmt.Errorf("no [%s] account", name)
+		return nil, "", nil, fmt.Errorf("no account mounted at [%s]", rawPath)
 	}
 	driver, ok := base.GetDriver(account.Type)
 	if !ok {
@@ -44,6 +36,32 @@ func ParsePath(rawPath string) (*model.Account, string, base.Driver, error) {
 	return &account, path, driver, nil
 }
 
+// RawPath convert the path in account to the path shown to users, the reverse of ParsePath
+func RawPath(account *model.Account, path string) string {
+	return utils.Join(account.MountPath, utils.ParsePath(path))
+}
+
+// WithVirtualFiles append the virtual folders leading to the accounts mounted under the path,
+// they replace the files of the account with the same name, like GetAccountByPath resolves the paths
+func WithVirtualFiles(rawPath string, files []model.File) []model.File {
+	virtual := model.GetVirtualFiles(rawPath)
+	if len(virtual) == 0 {
+		return files
+	}
+	// 嵌套挂载的账号覆盖上级账号中同名的文件
+	names := make(map[string]bool, len(virtual))
+	for _, file := range virtual {
+		names[file.Name] = true
+	}
+	res := make([]model.File, 0, len(files)+len(virtual))
+	for _, file := range files {
+		if !names[file.Name] {
+			res = append(res, file)
+		}
+	}
+	return append(res, virtual...)
+}
+
 func ErrorResp(c *gin.Context, err error, code int) {
 	log.Error(err.Error())
 	c.JSON(200, Resp{
@@ -69,3 +87,19 @@ func SuccessResp(c *gin.Context, data ...interface{}) {
 		Data:    data[0],
 	})
 }
+
+// SetParam set the param of the gin context, used to rewrite the path before passing to other handlers
+func SetParam(c *gin.Context, key string, value string) {
+	for i := range c.Params {
+		if c.Params[i].Key == key {
+			c.Params[i].Value = value
+			return
+		}
+	}
+	c.Params = append(c.Params, gin.Param{Key: key, Value: value})
+}
+
+// DownLink get the /d link of the real path, the link is signed so that DownCheck doesn't convert it to the real path again
+func DownLink(host string, path string) string {
+	return fmt.Sprintf("//%s/d%s?sign=%s", host, path, utils.SignPath(path))
+}
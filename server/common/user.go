@@ -0,0 +1,32 @@
+package common
+
+import (
+	"github.com/Xhofe/alist/conf"
+	"github.com/Xhofe/alist/model"
+	"github.com/gin-gonic/gin"
+)
+
+// GetUserByToken get the user of the token, the admin password token is treated as AdminUser
+func GetUserByToken(token string) *model.User {
+	if token == "" {
+		return nil
+	}
+	if token == conf.Token {
+		user := model.AdminUser
+		return &user
+	}
+	user, err := model.GetUserByToken(token)
+	if err != nil {
+		return nil
+	}
+	return user
+}
+
+// GetUser get the user set by the middlewares, nil for anonymous
+func GetUser(c *gin.Context) *model.User {
+	user, ok := c.Get("user")
+	if !ok {
+		return nil
+	}
+	return user.(*model.User)
+}
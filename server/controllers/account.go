@@ -31,6 +31,10 @@ func CreateAccount(c *gin.Context) {
 		common.ErrorResp(c, fmt.Errorf("no [%s] driver", req.Type), 400)
 		return
 	}
+	if err := model.CheckMountPath(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
 	now := time.Now()
 	req.UpdatedAt = &now
 	if err := model.CreateAccount(&req); err != nil {
@@ -62,11 +66,12 @@ func SaveAccount(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	if err = model.CheckMountPath(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
 	now := time.Now()
 	req.UpdatedAt = &now
-	if old.Name != req.Name {
-		model.DeleteAccountFromMap(old.Name)
-	}
 	if err := model.SaveAccount(&req); err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
@@ -2,7 +2,10 @@ package controllers
 
 import (
 	"github.com/Xhofe/alist/conf"
+	"github.com/Xhofe/alist/drivers/base"
+	"github.com/Xhofe/alist/model"
 	"github.com/Xhofe/alist/server/common"
+	"github.com/Xhofe/alist/utils"
 	"github.com/gin-gonic/gin"
 )
 
@@ -14,3 +17,43 @@ func ClearCache(c *gin.Context) {
 		common.SuccessResp(c)
 	}
 }
+
+type InvalidateCacheReq struct {
+	Path      string `json:"path"`
+	Recursive bool   `json:"recursive"`
+}
+
+// InvalidateCache 删除单个路径的缓存，recursive时同时删除其下所有路径以及挂载在其下的账号的缓存
+func InvalidateCache(c *gin.Context) {
+	var req InvalidateCacheReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Path = utils.ParsePath(req.Path)
+	var accounts []model.Account
+	var paths []string
+	if req.Recursive {
+		for _, account := range model.GetAccountsUnder(req.Path) {
+			accounts = append(accounts, account)
+			paths = append(paths, "/")
+		}
+	}
+	account, path, _, err := common.ParsePath(req.Path)
+	// 挂载在该路径的账号已经包含在上面
+	if err == nil && !(req.Recursive && path == "/") {
+		accounts = append(accounts, *account)
+		paths = append(paths, path)
+	}
+	if len(accounts) == 0 {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	for i := range accounts {
+		if err := base.InvalidateCache(paths[i], &accounts[i], req.Recursive); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	common.SuccessResp(c)
+}
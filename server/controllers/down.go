@@ -30,7 +30,7 @@ func Down(c *gin.Context) {
 		Proxy(c)
 		return
 	}
-	link, err := driver.Link(path, account)
+	link, err := base.GetLink(driver, account, path)
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
@@ -57,20 +57,29 @@ func Proxy(c *gin.Context) {
 		return
 	}
 	// 中转时有中转机器使用中转机器，若携带标志位则表明不能再走中转机器了
-	if account.ProxyUrl != "" && c.Param("d") != "1" {
-		name := utils.Base(rawPath)
-		link := fmt.Sprintf("%s%s?sign=%s", account.ProxyUrl, rawPath, utils.SignWithToken(name, conf.Token))
+	if account.ProxyUrl != "" && c.Query("d") != "1" {
+		link := fmt.Sprintf("%s%s?sign=%s", account.ProxyUrl, rawPath, utils.SignPath(rawPath))
 		c.Redirect(302, link)
 		return
 	}
-	link, err := driver.Link(path, account)
+	link, err := base.GetLink(driver, account, path)
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
 	}
 	// 本机读取数据
-	if account.Type == "FTP" {
-		c.Data(http.StatusOK, "application/octet-stream", link.Data)
+	if link.Reader != nil {
+		file, err := base.GetFile(driver, account, path)
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		reader := base.NewSeekReader(link.Reader, file.Size)
+		defer func() {
+			_ = reader.Close()
+		}()
+		http.ServeContent(c.Writer, c.Request, file.Name, file.ModTime(), reader)
+		return
 	}
 	// 本机文件直接返回文件
 	if account.Type == "Native" {
@@ -95,28 +104,60 @@ func Proxy(c *gin.Context) {
 		c.File(link.Url)
 		return
 	} else {
+		refresh := func() (*base.Link, error) {
+			return base.RefreshLink(driver, account, path)
+		}
 		if utils.GetFileType(filepath.Ext(rawPath)) == conf.TEXT {
-			Text(c, link)
+			Text(c, link, refresh)
 			return
 		}
 		driver.Proxy(c, account)
-		r := c.Request
-		w := c.Writer
-		target, err := url.Parse(link.Url)
-		if err != nil {
-			common.ErrorResp(c, err, 500)
-			return
+		proxyLink(c, link, refresh)
+	}
+}
+
+// proxyLink 中转链接的数据，上游返回链接过期时用refresh获取新的链接后重试一次
+func proxyLink(c *gin.Context, link *base.Link, refresh func() (*base.Link, error)) {
+	r := c.Request
+	w := c.Writer
+	target, err := url.Parse(link.Url)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	protocol := "http://"
+	if strings.HasPrefix(link.Url, "https://") {
+		protocol = "https://"
+	}
+	targetHost, err := url.Parse(fmt.Sprintf("%s%s", protocol, target.Host))
+	proxy := httputil.NewSingleHostReverseProxy(targetHost)
+	if refresh != nil {
+		expired := false
+		proxy.ModifyResponse = func(res *http.Response) error {
+			if base.IsLinkExpired(res.StatusCode) {
+				expired = true
+				return fmt.Errorf("link expired: %s", res.Status)
+			}
+			return nil
 		}
-		protocol := "http://"
-		if strings.HasPrefix(link.Url, "https://") {
-			protocol = "https://"
+		proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			if !expired {
+				log.Errorf("proxy error: %s", err.Error())
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			log.Debugf("refresh link of %s: %s", c.Param("path"), err.Error())
+			link, err := refresh()
+			if err != nil {
+				common.ErrorResp(c, err, 500)
+				return
+			}
+			proxyLink(c, link, nil)
 		}
-		targetHost, err := url.Parse(fmt.Sprintf("%s%s", protocol, target.Host))
-		proxy := httputil.NewSingleHostReverseProxy(targetHost)
-		r.URL = target
-		r.Host = target.Host
-		proxy.ServeHTTP(w, r)
 	}
+	r.URL = target
+	r.Host = target.Host
+	proxy.ServeHTTP(w, r)
 }
 
 var client *resty.Client
@@ -126,8 +167,14 @@ func init() {
 	client.SetRetryCount(3)
 }
 
-func Text(c *gin.Context, link *base.Link) {
+func Text(c *gin.Context, link *base.Link, refresh func() (*base.Link, error)) {
 	res, err := client.R().Get(link.Url)
+	if err == nil && refresh != nil && base.IsLinkExpired(res.StatusCode()) {
+		link, err = refresh()
+		if err == nil {
+			res, err = client.R().Get(link.Url)
+		}
+	}
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
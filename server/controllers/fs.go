@@ -0,0 +1,376 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Xhofe/alist/drivers/base"
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/server/common"
+	"github.com/Xhofe/alist/utils"
+	"github.com/gin-gonic/gin"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"strings"
+)
+
+type FsPathReq struct {
+	Path string `json:"path" binding:"required"`
+}
+
+type RenameReq struct {
+	Path string `json:"path" binding:"required"`
+	Name string `json:"name" binding:"required"`
+}
+
+type OfflineDownloadReq struct {
+	Url  string `json:"url" binding:"required"`
+	Path string `json:"path" binding:"required"` // 保存的文件夹
+	Name string `json:"name"`                    // 为空时使用url中的文件名
+}
+
+type FsSrcDstReq struct {
+	Src      string `json:"src" binding:"required"`
+	Dst      string `json:"dst" binding:"required"`
+	Password string `json:"password"` // 源路径的密码
+}
+
+// fsTarget 写操作的目标
+type fsTarget struct {
+	realPath string
+	account  *model.Account
+	path     string
+	driver   base.Driver
+}
+
+// parseFsPath chroot the path of the user and parse it, the root of accounts can't be written
+func parseFsPath(user *model.User, rawPath string) (*fsTarget, error) {
+	realPath := user.RealPath(rawPath)
+	account, path, driver, err := common.ParsePath(realPath)
+	if err != nil {
+		return nil, err
+	}
+	if utils.ParsePath(path) == "/" {
+		return nil, errors.New("can't operate the root folder")
+	}
+	return &fsTarget{
+		realPath: realPath,
+		account:  account,
+		path:     utils.ParsePath(path),
+		driver:   driver,
+	}, nil
+}
+
+func Mkdir(c *gin.Context) {
+	var req FsPathReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := common.GetUser(c)
+	target, err := parseFsPath(user, req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if !model.CanMkdir(user, utils.Dir(target.realPath)) {
+		common.ErrorResp(c, fmt.Errorf("permission denied"), 403)
+		return
+	}
+	if err = base.DoMakeDir(target.driver, target.account, target.path); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func Rename(c *gin.Context) {
+	var req RenameReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Name == "." || req.Name == ".." || strings.ContainsAny(req.Name, "/\\") {
+		common.ErrorResp(c, fmt.Errorf("invalid name: %s", req.Name), 400)
+		return
+	}
+	user := common.GetUser(c)
+	target, err := parseFsPath(user, req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if !model.CanModify(user, target.realPath) {
+		common.ErrorResp(c, fmt.Errorf("permission denied"), 403)
+		return
+	}
+	dst := utils.Join(utils.Dir(target.path), req.Name)
+	if err = base.DoMove(target.driver, target.account, target.path, dst); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// parseSrcDst parse the src and dst, check the src can be read like PathCheck and DownCheck, and the dst can be written
+func parseSrcDst(c *gin.Context) (*fsTarget, *fsTarget, bool) {
+	var req FsSrcDstReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, nil, false
+	}
+	user := common.GetUser(c)
+	src, err := parseFsPath(user, req.Src)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, nil, false
+	}
+	dst, err := parseFsPath(user, req.Dst)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, nil, false
+	}
+	if src.realPath == dst.realPath {
+		common.ErrorResp(c, fmt.Errorf("the src and dst are the same"), 400)
+		return nil, nil, false
+	}
+	if !user.IsAdmin() {
+		if !common.CheckPathPassword(src.realPath, req.Password) {
+			common.ErrorResp(c, fmt.Errorf("wrong password"), 401)
+			return nil, nil, false
+		}
+		if !model.CanDown(user, src.realPath) {
+			common.ErrorResp(c, fmt.Errorf("permission denied"), 403)
+			return nil, nil, false
+		}
+	}
+	if !model.CanUpload(user, utils.Dir(dst.realPath)) {
+		common.ErrorResp(c, fmt.Errorf("permission denied"), 403)
+		return nil, nil, false
+	}
+	return src, dst, true
+}
+
+func Move(c *gin.Context) {
+	src, dst, ok := parseSrcDst(c)
+	if !ok {
+		return
+	}
+	if !model.CanModify(common.GetUser(c), src.realPath) {
+		common.ErrorResp(c, fmt.Errorf("permission denied"), 403)
+		return
+	}
+	if src.account.Name != dst.account.Name {
+		startTransfer(c, src, dst, true)
+		return
+	}
+	if err := base.DoMove(src.driver, src.account, src.path, dst.path); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func Copy(c *gin.Context) {
+	src, dst, ok := parseSrcDst(c)
+	if !ok {
+		return
+	}
+	if src.account.Name != dst.account.Name {
+		startTransfer(c, src, dst, false)
+		return
+	}
+	if err := base.DoCopy(src.driver, src.account, src.path, dst.path); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// startTransfer 跨账号的复制和移动在后台任务中进行，返回任务的id用于查看进度
+func startTransfer(c *gin.Context, src *fsTarget, dst *fsTarget, move bool) {
+	task := model.Task{
+		Type:       model.TaskCopy,
+		SrcAccount: src.account.Name,
+		SrcPath:    src.path,
+		DstAccount: dst.account.Name,
+		DstPath:    dst.path,
+	}
+	if move {
+		task.Type = model.TaskMove
+	}
+	submitTask(c, &task)
+}
+
+func submitTask(c *gin.Context, task *model.Task) {
+	if _, err := base.SubmitTask(task); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"task_id": task.ID,
+	})
+}
+
+func Delete(c *gin.Context) {
+	var req FsPathReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := common.GetUser(c)
+	target, err := parseFsPath(user, req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if !model.CanModify(user, target.realPath) {
+		common.ErrorResp(c, fmt.Errorf("permission denied"), 403)
+		return
+	}
+	file, err := base.GetFile(target.driver, target.account, target.path)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	// 文件夹在后台任务中删除
+	if file.IsDir() {
+		submitTask(c, &model.Task{
+			Type:       model.TaskDelete,
+			DstAccount: target.account.Name,
+			DstPath:    target.path,
+		})
+		return
+	}
+	if err = base.DoDelete(target.driver, target.account, target.path); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// Upload upload a file with multipart/form-data, the field "path"(the folder) must be before
+// the "file" part, the file is saved to the temp folder and uploaded to the driver in a task
+func Upload(c *gin.Context) {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := common.GetUser(c)
+	dir := ""
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			common.ErrorResp(c, fmt.Errorf("no file"), 400)
+			return
+		}
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		if part.FileName() == "" {
+			value, err := ioutil.ReadAll(io.LimitReader(part, 4096))
+			_ = part.Close()
+			if err != nil {
+				common.ErrorResp(c, err, 400)
+				return
+			}
+			if part.FormName() == "path" {
+				dir = string(value)
+			}
+			continue
+		}
+		task, err := saveUpload(user, dir, part)
+		if err != nil {
+			if err == os.ErrPermission {
+				common.ErrorResp(c, fmt.Errorf("permission denied"), 403)
+			} else {
+				common.ErrorResp(c, err, 500)
+			}
+			return
+		}
+		submitTask(c, task)
+		return
+	}
+}
+
+// saveUpload save the uploading file to the temp folder, return the upload task
+func saveUpload(user *model.User, dir string, part *multipart.Part) (*model.Task, error) {
+	defer part.Close()
+	name := utils.Base("/" + part.FileName())
+	if name == "" || name == "." || name == ".." {
+		return nil, fmt.Errorf("invalid file name: %s", part.FileName())
+	}
+	target, err := parseFsPath(user, utils.Join(utils.ParsePath(dir), name))
+	if err != nil {
+		return nil, err
+	}
+	if !model.CanUpload(user, utils.Dir(target.realPath)) {
+		return nil, os.ErrPermission
+	}
+	if err = os.MkdirAll(base.TaskTempDir(), 0700); err != nil {
+		return nil, err
+	}
+	tempFile, err := ioutil.TempFile(base.TaskTempDir(), "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tempFile.Close()
+	}()
+	if _, err = io.Copy(tempFile, part); err != nil {
+		_ = os.Remove(tempFile.Name())
+		return nil, err
+	}
+	return &model.Task{
+		Type:       model.TaskUpload,
+		SrcPath:    tempFile.Name(),
+		DstAccount: target.account.Name,
+		DstPath:    target.path,
+	}, nil
+}
+
+// OfflineDownload download the url to the folder in a task, only for the admin
+func OfflineDownload(c *gin.Context) {
+	var req OfflineDownloadReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := common.GetUser(c)
+	if !user.IsAdmin() {
+		common.ErrorResp(c, fmt.Errorf("permission denied"), 403)
+		return
+	}
+	u, err := url.Parse(req.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		common.ErrorResp(c, fmt.Errorf("invalid url: %s", req.Url), 400)
+		return
+	}
+	name := req.Name
+	if name == "" {
+		name = utils.Base(u.Path)
+	}
+	if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+		common.ErrorResp(c, fmt.Errorf("invalid file name: %s", name), 400)
+		return
+	}
+	target, err := parseFsPath(user, utils.Join(utils.ParsePath(req.Path), name))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if !model.CanUpload(user, utils.Dir(target.realPath)) {
+		common.ErrorResp(c, fmt.Errorf("permission denied"), 403)
+		return
+	}
+	submitTask(c, &model.Task{
+		Type:       model.TaskDownload,
+		SrcPath:    req.Url,
+		DstAccount: target.account.Name,
+		DstPath:    target.path,
+	})
+}
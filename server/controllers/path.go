@@ -2,49 +2,53 @@ package controllers
 
 import (
 	"fmt"
-	"github.com/Xhofe/alist/conf"
 	"github.com/Xhofe/alist/drivers/base"
 	"github.com/Xhofe/alist/model"
 	"github.com/Xhofe/alist/server/common"
 	"github.com/Xhofe/alist/utils"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
-	"strings"
 )
 
 func Path(c *gin.Context) {
 	reqV, _ := c.Get("req")
 	req := reqV.(common.PathReq)
-	if model.AccountsCount() > 1 && req.Path == "/" {
-		files, err := model.GetAccountFiles()
-		if err != nil {
+	virtual := model.GetVirtualFiles(req.Path)
+	account, path, driver, err := common.ParsePath(req.Path)
+	if err != nil {
+		if len(virtual) == 0 {
 			common.ErrorResp(c, err, 500)
 			return
 		}
+		// 只有挂载路径的上级文件夹
 		c.JSON(200, common.Resp{
 			Code:    200,
 			Message: "folder",
-			Data:    files,
+			Data:    common.HideFiles(req.Path, virtual),
 		})
 		return
 	}
-	account, path, driver, err := common.ParsePath(req.Path)
+	file, files, err := base.GetPath(driver, account, path)
 	if err != nil {
-		common.ErrorResp(c, err, 500)
-		return
+		if len(virtual) == 0 {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		file, files = nil, nil
 	}
-	file, files, err := driver.Path(path, account)
-	if err != nil {
-		common.ErrorResp(c, err, 500)
-		return
+	if file != nil && len(virtual) > 0 {
+		file, files = nil, nil
 	}
 	if file != nil {
-		// 对于中转文件或只能中转,将链接修改为中转链接
-		if driver.Config().OnlyProxy || account.Proxy {
+		// 禁止下载时不返回上游的链接
+		if !model.CanDown(common.GetUser(c), req.Path) {
+			file.Url = ""
+		} else if driver.Config().OnlyProxy || account.Proxy {
+			// 对于中转文件或只能中转,将链接修改为中转链接
 			if account.ProxyUrl != "" {
-				file.Url = fmt.Sprintf("%s%s?sign=%s", account.ProxyUrl, req.Path, utils.SignWithToken(file.Name, conf.Token))
+				file.Url = fmt.Sprintf("%s%s?sign=%s", account.ProxyUrl, req.Path, utils.SignPath(req.Path))
 			} else {
-				file.Url = fmt.Sprintf("//%s/d%s", c.Request.Host, req.Path)
+				file.Url = common.DownLink(c.Request.Host, req.Path)
 			}
 		}
 		c.JSON(200, common.Resp{
@@ -53,17 +57,7 @@ func Path(c *gin.Context) {
 			Data:    []*model.File{file},
 		})
 	} else {
-		meta, _ := model.GetMetaByPath(req.Path)
-		if meta != nil && meta.Hide != "" {
-			tmpFiles := make([]model.File, 0)
-			hideFiles := strings.Split(meta.Hide, ",")
-			for _, item := range files {
-				if !utils.IsContain(hideFiles, item.Name) {
-					tmpFiles = append(tmpFiles, item)
-				}
-			}
-			files = tmpFiles
-		}
+		files = common.HideFiles(req.Path, common.WithVirtualFiles(req.Path, files))
 		c.JSON(200, common.Resp{
 			Code:    200,
 			Message: "folder",
@@ -90,11 +84,11 @@ func Link(c *gin.Context) {
 	}
 	if driver.Config().NoLink {
 		common.SuccessResp(c, base.Link{
-			Url: fmt.Sprintf("//%s/d%s?d=1&sign=%s", c.Request.Host, req.Path, utils.SignWithToken(utils.Base(rawPath), conf.Token)),
+			Url: fmt.Sprintf("//%s/d%s?d=1&sign=%s", c.Request.Host, req.Path, utils.SignPath(rawPath)),
 		})
 		return
 	}
-	link, err := driver.Link(path, account)
+	link, err := base.GetLink(driver, account, path)
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"fmt"
+	"github.com/Xhofe/alist/drivers/base"
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/server/common"
+	"github.com/Xhofe/alist/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+)
+
+type SearchReq struct {
+	Path     string `json:"path"`
+	Keyword  string `json:"keyword" binding:"required"`
+	Password string `json:"password"`
+}
+
+// searchFilter 过滤掉隐藏的或需要密码的搜索结果
+type searchFilter struct {
+	password string
+	metas    map[string]*model.Meta
+}
+
+func (f *searchFilter) meta(path string) *model.Meta {
+	if meta, ok := f.metas[path]; ok {
+		return meta
+	}
+	meta, _ := model.GetMetaByPath(path)
+	f.metas[path] = meta
+	return meta
+}
+
+func (f *searchFilter) visible(path string) bool {
+	for path != "/" {
+		dir, name := utils.Dir(path), utils.Base(path)
+		meta := f.meta(dir)
+		if meta != nil {
+			if meta.Password != "" && meta.Password != f.password {
+				return false
+			}
+			if meta.Hide != "" && utils.IsContain(strings.Split(meta.Hide, ","), name) {
+				return false
+			}
+		}
+		path = dir
+	}
+	return true
+}
+
+func Search(c *gin.Context) {
+	var req SearchReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Path = utils.ParsePath(req.Path)
+	user := common.GetUserByToken(c.GetHeader("Authorization"))
+	if user != nil {
+		req.Path = user.RealPath(req.Path)
+	}
+	var accounts []model.Account
+	var paths []string
+	// 挂载在该路径下的账号全部搜索
+	for _, account := range model.GetAccountsUnder(req.Path) {
+		if account.Search {
+			accounts = append(accounts, account)
+			paths = append(paths, "/")
+		}
+	}
+	account, path, _, err := common.ParsePath(req.Path)
+	if err == nil && path != "/" && account.Search {
+		accounts = append(accounts, *account)
+		paths = append(paths, path)
+	}
+	if len(accounts) == 0 {
+		if err == nil {
+			err = fmt.Errorf("[%s] not allowed search", account.Name)
+		}
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	filter := &searchFilter{
+		password: req.Password,
+		metas:    map[string]*model.Meta{},
+	}
+	admin := user != nil && user.IsAdmin()
+	res := make([]*model.File, 0)
+	for i := range accounts {
+		account := accounts[i]
+		driver, ok := base.GetDriver(account.Type)
+		if !ok {
+			continue
+		}
+		var files []*model.File
+		var err error
+		if model.IsIndexed(account.ID) {
+			files, err = searchIndex(&account, paths[i], req.Keyword)
+		} else {
+			files, err = base.Search(driver, paths[i], req.Keyword, &account)
+		}
+		if err != nil {
+			log.Errorf("search in account [%s] error: %s", account.Name, err.Error())
+			continue
+		}
+		for _, file := range files {
+			file.Path = common.RawPath(&account, file.Path)
+			if !admin && !filter.visible(utils.Join(file.Path, file.Name)) {
+				continue
+			}
+			if user != nil {
+				file.Path = user.ViewPath(file.Path)
+			}
+			res = append(res, file)
+		}
+	}
+	common.SuccessResp(c, res)
+}
+
+// searchIndex search in the offline index of the account
+func searchIndex(account *model.Account, path string, keyword string) ([]*model.File, error) {
+	searchFiles, err := model.SearchIndex(account.ID, utils.ParsePath(path), keyword)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]*model.File, 0, len(searchFiles))
+	for _, sf := range searchFiles {
+		file := sf.File(account.Type)
+		files = append(files, &file)
+	}
+	return files, nil
+}
+
+func RebuildIndex(c *gin.Context) {
+	account, ok := getAccountByQuery(c)
+	if !ok {
+		return
+	}
+	if base.IsIndexing(account.ID) {
+		common.ErrorResp(c, base.ErrIndexing, 400)
+		return
+	}
+	go func() {
+		if err := base.BuildIndex(account, true); err != nil {
+			log.Errorf("index account [%s] error: %s", account.Name, err.Error())
+		}
+	}()
+	common.SuccessResp(c)
+}
+
+func ClearIndex(c *gin.Context) {
+	account, ok := getAccountByQuery(c)
+	if !ok {
+		return
+	}
+	if base.IsIndexing(account.ID) {
+		common.ErrorResp(c, base.ErrIndexing, 400)
+		return
+	}
+	if err := model.ClearSearchFiles(account.ID); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func getAccountByQuery(c *gin.Context) (*model.Account, bool) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, false
+	}
+	dbAccount, err := model.GetAccountById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, false
+	}
+	account, ok := model.GetAccount(dbAccount.Name)
+	if !ok {
+		common.ErrorResp(c, fmt.Errorf("no [%s] account", dbAccount.Name), 400)
+		return nil, false
+	}
+	return &account, true
+}
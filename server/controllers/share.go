@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"fmt"
+	"github.com/Xhofe/alist/drivers/base"
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/server/common"
+	"github.com/Xhofe/alist/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Share 访问分享的文件或文件夹，文件夹内的文件通过 /s/:id/*path 访问
+func Share(c *gin.Context) {
+	share, err := model.GetShareById(c.Param("id"))
+	if err != nil {
+		common.ErrorResp(c, fmt.Errorf("share not found"), 404)
+		return
+	}
+	if share.Expired() {
+		common.ErrorResp(c, fmt.Errorf("share expired"), 410)
+		return
+	}
+	if !share.CheckPassword(c.Query("pw")) {
+		common.ErrorResp(c, fmt.Errorf("wrong password"), 401)
+		return
+	}
+	rawPath := share.Path
+	subPath := path.Clean("/" + c.Param("path"))
+	if subPath != "/" {
+		// 列目录时隐藏的文件也不能直接访问
+		for _, name := range strings.Split(strings.Trim(subPath, "/"), "/") {
+			rawPath = utils.Join(rawPath, name)
+			if common.IsHidden(rawPath) {
+				common.ErrorResp(c, fmt.Errorf("file not found"), 404)
+				return
+			}
+		}
+		rawPath = utils.ParsePath(rawPath)
+	}
+	account, path_, driver, err := common.ParsePath(rawPath)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	file, files, err := base.GetPath(driver, account, path_)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	shareLog := model.ShareLog{
+		ShareId:   share.ID,
+		Path:      subPath,
+		Ip:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Download:  file != nil && isNewDownload(c),
+	}
+	if err = model.CreateShareLog(&shareLog); err != nil {
+		log.Errorf("failed create share log: %s", err.Error())
+	}
+	if file == nil {
+		c.JSON(200, common.Resp{
+			Code:    200,
+			Message: "folder",
+			Data:    common.HideFiles(rawPath, files),
+		})
+		return
+	}
+	if isNewDownload(c) {
+		ok, err := model.IncreaseShareDownloads(share.ID)
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		if !ok {
+			common.ErrorResp(c, fmt.Errorf("reach the max downloads"), 403)
+			return
+		}
+	}
+	common.SetParam(c, "path", rawPath)
+	Down(c)
+}
+
+// isNewDownload 播放器拖动进度、下载器分段下载时会发送多个Range请求，只有从头开始的请求计为一次下载
+func isNewDownload(c *gin.Context) bool {
+	if c.Request.Method == http.MethodHead {
+		return false
+	}
+	r := strings.TrimSpace(c.GetHeader("Range"))
+	return r == "" || r == "bytes=0-"
+}
+
+func GetShares(c *gin.Context) {
+	shares, err := model.GetShares()
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, shares)
+}
+
+func CreateShare(c *gin.Context) {
+	var req model.Share
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Path = utils.ParsePath(req.Path)
+	account, path_, driver, err := common.ParsePath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if _, err = base.GetFile(driver, account, path_); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err = model.CreateShare(&req); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+func DeleteShare(c *gin.Context) {
+	id := c.Query("id")
+	if err := model.DeleteShare(id); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func GetShareLogs(c *gin.Context) {
+	logs, err := model.GetShareLogs(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, logs)
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"github.com/Xhofe/alist/drivers/base"
+	"github.com/Xhofe/alist/server/common"
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+func GetTasks(c *gin.Context) {
+	tasks, err := base.GetTasks()
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, tasks)
+}
+
+func GetTask(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	task, err := base.GetTask(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	common.SuccessResp(c, task)
+}
+
+func CancelTask(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err = base.CancelTask(uint(id)); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func RetryTask(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err = base.RetryTask(uint(id)); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -2,26 +2,33 @@ package middlewares
 
 import (
 	"fmt"
-	"github.com/Xhofe/alist/conf"
 	"github.com/Xhofe/alist/server/common"
 	"github.com/gin-gonic/gin"
 )
 
 func Auth(c *gin.Context) {
 	token := c.GetHeader("Authorization")
-	//password, err := model.GetSettingByKey("password")
-	//if err != nil {
-	//	if err == gorm.ErrRecordNotFound {
-	//		common.ErrorResp(c, fmt.Errorf("password not set"), 400)
-	//		return
-	//	}
-	//	common.ErrorResp(c, err, 500)
-	//	return
-	//}
-	//if token != utils.GetMD5Encode(password.Value) {
-	if token != conf.Token {
+	user := common.GetUserByToken(token)
+	if user == nil {
 		common.ErrorResp(c, fmt.Errorf("wrong password"), 401)
 		return
 	}
+	if !user.IsAdmin() {
+		common.ErrorResp(c, fmt.Errorf("permission denied"), 403)
+		return
+	}
+	c.Set("user", user)
+	c.Next()
+}
+
+// UserAuth allow all the logged-in users, the permissions are checked in handlers
+func UserAuth(c *gin.Context) {
+	token := c.GetHeader("Authorization")
+	user := common.GetUserByToken(token)
+	if user == nil {
+		common.ErrorResp(c, fmt.Errorf("wrong password"), 401)
+		return
+	}
+	c.Set("user", user)
 	c.Next()
-}
\ No newline at end of file
+}
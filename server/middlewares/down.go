@@ -2,18 +2,36 @@ package middlewares
 
 import (
 	"fmt"
-	"github.com/Xhofe/alist/conf"
+	"github.com/Xhofe/alist/model"
 	"github.com/Xhofe/alist/server/common"
 	"github.com/Xhofe/alist/utils"
 	"github.com/gin-gonic/gin"
 )
 
 func DownCheck(c *gin.Context) {
-	sign := c.Query("sign")
 	rawPath := c.Param("path")
 	rawPath = utils.ParsePath(rawPath)
-	name := utils.Base(rawPath)
-	if sign == utils.SignWithToken(name, conf.Token) {
+	// 签名的链接中已经是真实路径(见common.DownLink)
+	signed := utils.CheckSign(rawPath, c.Query("sign"))
+	// 未签名的链接是用户看到的路径，携带用户token时转换为用户的真实路径
+	user := common.GetUserByToken(c.GetHeader("Authorization"))
+	if user != nil {
+		if !signed {
+			rawPath = user.RealPath(rawPath)
+			common.SetParam(c, "path", rawPath)
+		}
+		c.Set("user", user)
+		if user.IsAdmin() {
+			c.Next()
+			return
+		}
+	}
+	// 签名的链接也不能绕过禁止下载
+	if !model.CanDown(user, rawPath) {
+		common.ErrorResp(c, fmt.Errorf("download is disabled"), 403)
+		return
+	}
+	if signed {
 		c.Next()
 		return
 	}
@@ -24,4 +42,4 @@ func DownCheck(c *gin.Context) {
 		return
 	}
 	c.Next()
-}
\ No newline at end of file
+}
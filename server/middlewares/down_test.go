@@ -0,0 +1,56 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Xhofe/alist/conf"
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/server/common"
+	"github.com/Xhofe/alist/utils/testdb"
+	"github.com/gin-gonic/gin"
+)
+
+// 列目录返回的下载链接是签名的真实路径，不会再次转换；未签名的链接是用户看到的路径
+func TestDownCheckBasePath(t *testing.T) {
+	testdb.Init(t, &model.User{}, &model.Meta{})
+	conf.Token = "token"
+	user := model.User{Username: "foo", Password: "pw", BasePath: "/foo"}
+	if err := model.CreateUser(&user); err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/d/*path", DownCheck, func(c *gin.Context) {
+		c.String(200, c.Param("path"))
+	})
+	link := common.DownLink("example.com", user.RealPath("/bar.txt"))
+	tests := []struct {
+		name  string
+		url   string
+		token string
+		want  string
+	}{
+		{"signed with token", strings.TrimPrefix(link, "//example.com"), user.Token, "/foo/bar.txt"},
+		{"signed without token", strings.TrimPrefix(link, "//example.com"), "", "/foo/bar.txt"},
+		{"view path with token", "/d/bar.txt", user.Token, "/foo/bar.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", tt.token)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if got := w.Body.String(); got != tt.want {
+				var resp common.Resp
+				_ = json.Unmarshal(w.Body.Bytes(), &resp)
+				t.Errorf("path = %q, want %q (%s)", got, tt.want, resp.Message)
+			}
+		})
+	}
+}
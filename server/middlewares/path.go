@@ -2,8 +2,6 @@ package middlewares
 
 import (
 	"fmt"
-	"github.com/Xhofe/alist/conf"
-	"github.com/Xhofe/alist/model"
 	"github.com/Xhofe/alist/server/common"
 	"github.com/Xhofe/alist/utils"
 	"github.com/gin-gonic/gin"
@@ -16,25 +14,20 @@ func PathCheck(c *gin.Context) {
 		return
 	}
 	req.Path = utils.ParsePath(req.Path)
-	c.Set("req",req)
-	token := c.GetHeader("Authorization")
-	if token == conf.Token {
+	user := common.GetUserByToken(c.GetHeader("Authorization"))
+	if user != nil {
+		req.Path = user.RealPath(req.Path)
+		c.Set("user", user)
+	}
+	c.Set("req", req)
+	if user != nil && user.IsAdmin() {
 		c.Next()
 		return
 	}
-	meta, err := model.GetMetaByPath(req.Path)
-	if err == nil {
-		if meta.Password != "" && meta.Password != req.Password {
-			common.ErrorResp(c, fmt.Errorf("wrong password"), 401)
-			c.Abort()
-			return
-		}
-	} else if conf.CheckParent {
-		if !common.CheckParent(utils.Dir(req.Path), req.Password) {
-			common.ErrorResp(c, fmt.Errorf("wrong password"), 401)
-			c.Abort()
-			return
-		}
+	if !common.CheckPathPassword(req.Path, req.Password) {
+		common.ErrorResp(c, fmt.Errorf("wrong password"), 401)
+		c.Abort()
+		return
 	}
 	c.Next()
-}
\ No newline at end of file
+}
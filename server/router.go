@@ -14,6 +14,8 @@ func InitApiRouter(r *gin.Engine) {
 	Cors(r)
 	r.GET("/d/*path", middlewares.DownCheck, controllers.Down)
 	r.GET("/p/*path", middlewares.DownCheck, controllers.Proxy)
+	r.GET("/s/:id", controllers.Share)
+	r.GET("/s/:id/*path", controllers.Share)
 
 	api := r.Group("/api")
 	public := api.Group("/public")
@@ -25,6 +27,8 @@ func InitApiRouter(r *gin.Engine) {
 		//path.POST("/link",middlewares.Auth, controllers.Link)
 
 		public.GET("/settings", controllers.GetSettingsPublic)
+		public.POST("/search", middlewares.CheckAccount, controllers.Search)
+		public.POST("/login", controllers.Login)
 	}
 
 	admin := api.Group("/admin")
@@ -41,6 +45,7 @@ func InitApiRouter(r *gin.Engine) {
 		admin.DELETE("/account", controllers.DeleteAccount)
 		admin.GET("/drivers", controllers.GetDrivers)
 		admin.GET("/clear_cache", controllers.ClearCache)
+		admin.POST("/invalidate_cache", controllers.InvalidateCache)
 
 		admin.GET("/metas", controllers.GetMetas)
 		admin.POST("/meta/create", controllers.CreateMeta)
@@ -48,6 +53,36 @@ func InitApiRouter(r *gin.Engine) {
 		admin.DELETE("/meta", controllers.DeleteMeta)
 
 		admin.POST("/link", controllers.Link)
+
+		admin.GET("/users", controllers.GetUsers)
+		admin.POST("/user/create", controllers.CreateUser)
+		admin.POST("/user/save", controllers.SaveUser)
+		admin.DELETE("/user", controllers.DeleteUser)
+
+		admin.GET("/shares", controllers.GetShares)
+		admin.POST("/share/create", controllers.CreateShare)
+		admin.DELETE("/share", controllers.DeleteShare)
+		admin.GET("/share/logs", controllers.GetShareLogs)
+
+		admin.POST("/index/rebuild", controllers.RebuildIndex)
+		admin.DELETE("/index", controllers.ClearIndex)
+
+		admin.GET("/tasks", controllers.GetTasks)
+		admin.GET("/task", controllers.GetTask)
+		admin.POST("/task/cancel", controllers.CancelTask)
+		admin.POST("/task/retry", controllers.RetryTask)
+	}
+
+	// 写操作对所有登录用户开放,具体权限由meta决定
+	fs := api.Group("/admin/fs", middlewares.UserAuth, middlewares.CheckAccount)
+	{
+		fs.POST("/mkdir", controllers.Mkdir)
+		fs.POST("/rename", controllers.Rename)
+		fs.POST("/move", controllers.Move)
+		fs.POST("/copy", controllers.Copy)
+		fs.POST("/delete", controllers.Delete)
+		fs.POST("/upload", controllers.Upload)
+		fs.POST("/offline", controllers.OfflineDownload)
 	}
 	Static(r)
 	WebDav(r)
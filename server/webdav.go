@@ -2,6 +2,8 @@ package server
 
 import (
 	"github.com/Xhofe/alist/conf"
+	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/server/common"
 	"github.com/Xhofe/alist/server/webdav"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -32,8 +34,14 @@ func WebDav(r *gin.Engine) {
 }
 
 func ServeWebDAV(c *gin.Context) {
-	fs := webdav.FileSystem{}
-	handler.ServeHTTP(c.Writer,c.Request,&fs)
+	user := common.GetUser(c)
+	// 只有OPTIONS不需要验证
+	if user == nil && c.Request.Method != "OPTIONS" {
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+	fs := webdav.FileSystem{User: user}
+	handler.ServeHTTP(c.Writer, c.Request, &fs)
 }
 
 func WebDAVAuth(c *gin.Context) {
@@ -48,13 +56,20 @@ func WebDAVAuth(c *gin.Context) {
 		c.Abort()
 		return
 	}
-	if conf.DavUsername != "" && conf.DavUsername != username {
-		c.Status(http.StatusUnauthorized)
-		c.Abort()
+	user, err := model.CheckUserCached(username, password)
+	if err == nil {
+		c.Set("user", user)
+		c.Next()
+		return
 	}
-	if conf.DavPassword != "" && conf.DavPassword != password {
+	// 设置中的WebDAV用户名密码拥有全部权限
+	if (conf.DavUsername != "" && conf.DavUsername != username) ||
+		(conf.DavPassword != "" && conf.DavPassword != password) {
 		c.Status(http.StatusUnauthorized)
 		c.Abort()
+		return
 	}
+	admin := model.AdminUser
+	c.Set("user", &admin)
 	c.Next()
-}
\ No newline at end of file
+}
@@ -10,74 +10,93 @@ import (
 	"github.com/Xhofe/alist/conf"
 	"github.com/Xhofe/alist/drivers/base"
 	"github.com/Xhofe/alist/model"
+	"github.com/Xhofe/alist/server/common"
 	"github.com/Xhofe/alist/utils"
 	log "github.com/sirupsen/logrus"
 	"net/http"
+	"os"
 	"path"
 	"path/filepath"
-	"strings"
 	"time"
 )
 
-type FileSystem struct{}
+type FileSystem struct {
+	User *model.User
+}
+
+var errPermissionDenied = os.ErrPermission
+
+// realPath convert the request path to the real path of the user
+func (fs *FileSystem) realPath(rawPath string) string {
+	if fs.User == nil {
+		return utils.ParsePath(rawPath)
+	}
+	return fs.User.RealPath(rawPath)
+}
+
+// noPermissionUser 没有通过验证的请求(如OPTIONS)只有访客的权限
+var noPermissionUser = model.User{Username: "guest", Role: model.GUEST, BasePath: "/"}
+
+func (fs *FileSystem) user() *model.User {
+	if fs.User == nil {
+		user := noPermissionUser
+		return &user
+	}
+	return fs.User
+}
 
-func ParsePath(rawPath string) (*model.Account, string, base.Driver, error) {
-	var internalPath, name string
-	switch model.AccountsCount() {
-	case 0:
-		return nil, "", nil, fmt.Errorf("no accounts,please add one first")
-	case 1:
-		internalPath = rawPath
-		break
-	default:
-		paths := strings.Split(rawPath, "/")
-		internalPath = "/" + strings.Join(paths[2:], "/")
-		name = paths[1]
-	}
-	account, ok := model.GetAccount(name)
-	if !ok {
-		return nil, "", nil, fmt.Errorf("no [%s] account", name)
-	}
-	driver, ok := base.GetDriver(account.Type)
-	if !ok {
-		return nil, "", nil, fmt.Errorf("no [%s] driver", account.Type)
-	}
-	return &account, internalPath, driver, nil
+// canWrite check the permission of the real path, and whether it can be written through WebDAV
+func (fs *FileSystem) canWrite(realPath string, check func(user *model.User, path string) bool) bool {
+	return check(fs.user(), realPath) && model.CanWebdavWrite(fs.user(), realPath)
 }
 
 func (fs *FileSystem) File(rawPath string) (*model.File, error) {
-	rawPath = utils.ParsePath(rawPath)
-	if model.AccountsCount() > 1 && rawPath == "/" {
-		now := time.Now()
-		return &model.File{
-			Name:      "root",
-			Size:      0,
-			Type:      conf.FOLDER,
-			Driver:    "root",
-			UpdatedAt: &now,
-		}, nil
-	}
-	account, path_, driver, err := ParsePath(rawPath)
-	if err != nil {
+	rawPath = fs.realPath(rawPath)
+	virtual := model.GetVirtualFiles(rawPath)
+	account, path_, driver, err := common.ParsePath(rawPath)
+	if err == nil {
+		var file *model.File
+		file, err = base.GetFile(driver, account, path_)
+		if err == nil && (file.IsDir() || len(virtual) == 0) {
+			return file, nil
+		}
+	}
+	if len(virtual) == 0 {
 		return nil, err
 	}
-	return driver.File(path_, account)
+	// 挂载路径的上级文件夹
+	now := time.Now()
+	name := utils.Base(rawPath)
+	if rawPath == "/" {
+		name = "root"
+	}
+	return &model.File{
+		Name:      name,
+		Size:      0,
+		Type:      conf.FOLDER,
+		Driver:    "root",
+		UpdatedAt: &now,
+	}, nil
 }
 
 func (fs *FileSystem) Files(rawPath string) ([]model.File, error) {
-	rawPath = utils.ParsePath(rawPath)
-	if model.AccountsCount() > 1 && rawPath == "/" {
-		files, err := model.GetAccountFiles()
-		if err != nil {
+	rawPath = fs.realPath(rawPath)
+	virtual := model.GetVirtualFiles(rawPath)
+	account, path_, driver, err := common.ParsePath(rawPath)
+	if err != nil {
+		if len(virtual) == 0 {
 			return nil, err
 		}
-		return files, nil
+		return virtual, nil
 	}
-	account, path_, driver, err := ParsePath(rawPath)
+	files, err := base.GetFiles(driver, account, path_)
 	if err != nil {
-		return nil, err
+		if len(virtual) == 0 {
+			return nil, err
+		}
+		files = nil
 	}
-	return driver.Files(path_, account)
+	return common.WithVirtualFiles(rawPath, files), nil
 }
 
 //func GetPW(path string, name string) string {
@@ -102,12 +121,12 @@ func (fs *FileSystem) Files(rawPath string) ([]model.File, error) {
 //}
 
 func (fs *FileSystem) Link(r *http.Request, rawPath string) (string, error) {
-	rawPath = utils.ParsePath(rawPath)
-	log.Debugf("get link path: %s", rawPath)
-	if model.AccountsCount() > 1 && rawPath == "/" {
-		// error
+	rawPath = fs.realPath(rawPath)
+	if !model.CanDown(fs.user(), rawPath) {
+		return "", errPermissionDenied
 	}
-	account, path_, driver, err := ParsePath(rawPath)
+	log.Debugf("get link path: %s", rawPath)
+	account, path_, driver, err := common.ParsePath(rawPath)
 	if err != nil {
 		return "", err
 	}
@@ -117,13 +136,9 @@ func (fs *FileSystem) Link(r *http.Request, rawPath string) (string, error) {
 		protocol = "https"
 	}
 	if driver.Config().OnlyProxy || account.WebdavProxy {
-		link = fmt.Sprintf("%s://%s/p%s", protocol, r.Host, rawPath)
-		if conf.CheckDown {
-			sign := utils.SignWithToken(utils.Base(rawPath), conf.Token)
-			link += "?sign" + sign
-		}
+		link = fmt.Sprintf("%s://%s/p%s?sign=%s", protocol, r.Host, rawPath, utils.SignPath(rawPath))
 	} else {
-		link_, err := driver.Link(path_, account)
+		link_, err := base.GetLink(driver, account, path_)
 		if err != nil {
 			return "", err
 		}
@@ -133,30 +148,56 @@ func (fs *FileSystem) Link(r *http.Request, rawPath string) (string, error) {
 	return link, err
 }
 
-func (fs *FileSystem) CreateDirectory(ctx context.Context, rawPath string) error {
-	rawPath = utils.ParsePath(rawPath)
-	if rawPath == "/" {
-		return ErrNotImplemented
+// Reader get the seekable reader of the file only can be read on this server, nil if not
+func (fs *FileSystem) Reader(rawPath string, size int64) (*base.SeekReader, error) {
+	rawPath = fs.realPath(rawPath)
+	if !model.CanDown(fs.user(), rawPath) {
+		return nil, errPermissionDenied
 	}
-	if model.AccountsCount() > 1 && len(strings.Split(rawPath, "/")) < 2 {
-		return ErrNotImplemented
+	account, path_, driver, err := common.ParsePath(rawPath)
+	if err != nil {
+		return nil, err
+	}
+	if !driver.Config().OnlyProxy {
+		return nil, nil
 	}
-	account, path_, driver, err := ParsePath(rawPath)
+	link, err := base.GetLink(driver, account, path_)
+	if err != nil {
+		return nil, err
+	}
+	if link.Reader == nil {
+		return nil, nil
+	}
+	return base.NewSeekReader(link.Reader, size), nil
+}
+
+func (fs *FileSystem) CreateDirectory(ctx context.Context, rawPath string) error {
+	rawPath = fs.realPath(rawPath)
+	if !fs.canWrite(utils.Dir(rawPath), model.CanMkdir) {
+		return errPermissionDenied
+	}
+	account, path_, driver, err := common.ParsePath(rawPath)
 	if err != nil {
 		return err
 	}
-	return driver.MakeDir(path_,account)
+	if path_ == "/" {
+		return ErrNotImplemented
+	}
+	return base.DoMakeDir(driver, account, path_)
 }
 
 func (fs *FileSystem) Upload(ctx context.Context, r *http.Request, rawPath string) error {
-	rawPath = utils.ParsePath(rawPath)
-	if model.AccountsCount() > 1 && rawPath == "/" {
-		return ErrNotImplemented
+	rawPath = fs.realPath(rawPath)
+	if !fs.canWrite(utils.Dir(rawPath), model.CanUpload) {
+		return errPermissionDenied
 	}
-	account, path_, driver, err := ParsePath(rawPath)
+	account, path_, driver, err := common.ParsePath(rawPath)
 	if err != nil {
 		return err
 	}
+	if path_ == "/" {
+		return ErrNotImplemented
+	}
 	//fileSize, err := strconv.ParseUint(r.Header.Get("Content-Length"), 10, 64)
 	fileSize := uint64(r.ContentLength)
 	//if err != nil {
@@ -169,23 +210,25 @@ func (fs *FileSystem) Upload(ctx context.Context, r *http.Request, rawPath strin
 		Size:       fileSize,
 		Name:       fileName,
 		ParentPath: filePath,
+		Ctx:        r.Context(),
 	}
-	return driver.Upload(&fileData, account)
+	return base.DoUpload(driver, account, &fileData)
 }
 
 func (fs *FileSystem) Delete(rawPath string) error {
-	rawPath = utils.ParsePath(rawPath)
-	if rawPath == "/" {
-		return ErrNotImplemented
-	}
-	if model.AccountsCount() > 1 && len(strings.Split(rawPath, "/")) < 2 {
-		return ErrNotImplemented
+	rawPath = fs.realPath(rawPath)
+	if !fs.canWrite(rawPath, model.CanModify) {
+		return errPermissionDenied
 	}
-	account, path_, driver, err := ParsePath(rawPath)
+	account, path_, driver, err := common.ParsePath(rawPath)
 	if err != nil {
 		return err
 	}
-	return driver.Delete(path_, account)
+	// 不能删除账号的根目录
+	if path_ == "/" {
+		return ErrNotImplemented
+	}
+	return base.DoDelete(driver, account, path_)
 }
 
 // slashClean is equivalent to but slightly more efficient than
@@ -201,24 +244,41 @@ func slashClean(name string) string {
 //
 // See section 9.9.4 for when various HTTP status codes apply.
 func moveFiles(ctx context.Context, fs *FileSystem, src string, dst string, overwrite bool) (status int, err error) {
-	src = utils.ParsePath(src)
-	dst = utils.ParsePath(dst)
+	src = fs.realPath(src)
+	dst = fs.realPath(dst)
 	log.Debugf("move %s -> %s", src, dst)
 	if src == dst {
 		return http.StatusMethodNotAllowed, errDestinationEqualsSource
 	}
-	srcAccount, srcPath, driver, err := ParsePath(src)
+	if !fs.canWrite(src, model.CanModify) || !fs.canWrite(utils.Dir(dst), model.CanUpload) {
+		return http.StatusForbidden, errPermissionDenied
+	}
+	srcAccount, srcPath, driver, err := common.ParsePath(src)
 	if err != nil {
 		return http.StatusMethodNotAllowed, err
 	}
-	dstAccount, dstPath, _, err := ParsePath(dst)
+	dstAccount, dstPath, _, err := common.ParsePath(dst)
 	if err != nil {
 		return http.StatusMethodNotAllowed, err
 	}
+	// 账号的根目录不能移动或覆盖
+	if srcPath == "/" || dstPath == "/" {
+		return http.StatusForbidden, errPermissionDenied
+	}
 	if srcAccount.Name != dstAccount.Name {
-		return http.StatusMethodNotAllowed, errInvalidDestination
+		err = base.WaitTask(&model.Task{
+			Type:       model.TaskMove,
+			SrcAccount: srcAccount.Name,
+			SrcPath:    srcPath,
+			DstAccount: dstAccount.Name,
+			DstPath:    dstPath,
+		})
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		return http.StatusCreated, nil
 	}
-	err = driver.Move(srcPath,dstPath,srcAccount)
+	err = base.DoMove(driver, srcAccount, srcPath, dstPath)
 	if err != nil {
 		log.Debug(err)
 		return http.StatusInternalServerError, err
@@ -230,25 +290,40 @@ func moveFiles(ctx context.Context, fs *FileSystem, src string, dst string, over
 //
 // See section 9.8.5 for when various HTTP status codes apply.
 func copyFiles(ctx context.Context, fs *FileSystem, src string, dst string, overwrite bool, depth int, recursion int) (status int, err error) {
-	src = utils.ParsePath(src)
-	dst = utils.ParsePath(dst)
-	log.Debugf("move %s -> %s", src, dst)
+	src = fs.realPath(src)
+	dst = fs.realPath(dst)
+	log.Debugf("copy %s -> %s", src, dst)
 	if src == dst {
 		return http.StatusMethodNotAllowed, errDestinationEqualsSource
 	}
-	srcAccount, srcPath, driver, err := ParsePath(src)
+	if !fs.canWrite(utils.Dir(dst), model.CanUpload) {
+		return http.StatusForbidden, errPermissionDenied
+	}
+	srcAccount, srcPath, driver, err := common.ParsePath(src)
 	if err != nil {
 		return http.StatusMethodNotAllowed, err
 	}
-	dstAccount, dstPath, _, err := ParsePath(dst)
+	dstAccount, dstPath, _, err := common.ParsePath(dst)
 	if err != nil {
 		return http.StatusMethodNotAllowed, err
 	}
+	if dstPath == "/" {
+		return http.StatusForbidden, errPermissionDenied
+	}
 	if srcAccount.Name != dstAccount.Name {
-		// TODO 跨账号复制
-		return http.StatusMethodNotAllowed, errInvalidDestination
+		err = base.WaitTask(&model.Task{
+			Type:       model.TaskCopy,
+			SrcAccount: srcAccount.Name,
+			SrcPath:    srcPath,
+			DstAccount: dstAccount.Name,
+			DstPath:    dstPath,
+		})
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		return http.StatusCreated, nil
 	}
-	err = driver.Copy(srcPath,dstPath,srcAccount)
+	err = base.DoCopy(driver, srcAccount, srcPath, dstPath)
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
@@ -234,7 +234,25 @@ func (h *Handler) handleGetHeadPost(w http.ResponseWriter, r *http.Request, fs *
 	}
 	w.Header().Set("ETag", etag)
 	log.Debugf("url: %+v", r.URL)
+	// 只能本机读取的数据直接返回，支持Range
+	reader, err := fs.Reader(reqPath, int64(file.GetSize()))
+	if err == errPermissionDenied {
+		return http.StatusForbidden, err
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if reader != nil {
+		defer func() {
+			_ = reader.Close()
+		}()
+		http.ServeContent(w, r, file.GetName(), file.ModTime(), reader)
+		return 0, nil
+	}
 	link, err := fs.Link(r, reqPath)
+	if err == errPermissionDenied {
+		return http.StatusForbidden, err
+	}
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
@@ -255,6 +273,9 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request, fs *FileS
 	}
 	defer release()
 	err = fs.Delete(reqPath)
+	if err == errPermissionDenied {
+		return http.StatusForbidden, err
+	}
 	if err != nil {
 		return http.StatusMethodNotAllowed, err
 	}
@@ -278,6 +299,9 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request, fs *FileSyst
 	defer cancel()
 
 	err = fs.Upload(ctx, r, reqPath)
+	if err == errPermissionDenied {
+		return http.StatusForbidden, err
+	}
 	if err != nil {
 		return http.StatusMethodNotAllowed, err
 	}
@@ -314,6 +338,9 @@ func (h *Handler) handleMkcol(w http.ResponseWriter, r *http.Request, fs *FileSy
 		//}
 	}
 	if err := fs.CreateDirectory(ctx, reqPath); err != nil {
+		if err == errPermissionDenied {
+			return http.StatusForbidden, err
+		}
 		return http.StatusConflict, err
 	}
 	return http.StatusCreated, nil
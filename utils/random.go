@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// RandomStr generate a random string with crypto/rand
+func RandomStr(n int) string {
+	b := make([]byte, n)
+	max := big.NewInt(int64(len(letters)))
+	for i := range b {
+		r, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		b[i] = letters[r.Int64()]
+	}
+	return string(b)
+}
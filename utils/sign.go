@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"github.com/Xhofe/alist/conf"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sign sign the full path and the expire timestamp with HMAC-SHA256, expire 0 means never expire
+func Sign(path string, expire int64, key string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(fmt.Sprintf("%s:%d", ParsePath(path), expire)))
+	return fmt.Sprintf("%s:%d", base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), expire)
+}
+
+// VerifySign check the sign generated by Sign
+func VerifySign(path string, sign string, key string) bool {
+	idx := strings.LastIndex(sign, ":")
+	if idx == -1 {
+		return false
+	}
+	expire, err := strconv.ParseInt(sign[idx+1:], 10, 64)
+	if err != nil {
+		return false
+	}
+	if expire != 0 && expire < time.Now().Unix() {
+		return false
+	}
+	return hmac.Equal([]byte(sign), []byte(Sign(path, expire, key)))
+}
+
+// SignWithKey sign the path with the key and the expiration in settings
+func SignWithKey(path string, key string) string {
+	var expire int64
+	if conf.SignExpire > 0 {
+		expire = time.Now().Add(conf.SignExpire).Unix()
+	}
+	return Sign(path, expire, key)
+}
+
+// SignPath sign the path with the token and the expiration in settings
+func SignPath(path string) string {
+	return SignWithKey(path, conf.Token)
+}
+
+// CheckSign verify the sign of the path, accept the legacy sign if enabled in settings
+func CheckSign(path string, sign string) bool {
+	if sign == "" {
+		return false
+	}
+	if VerifySign(path, sign, conf.Token) {
+		return true
+	}
+	return conf.LegacySign && sign == SignWithToken(Base(path), conf.Token)
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifySign(t *testing.T) {
+	const key = "key"
+	future := time.Now().Add(time.Hour).Unix()
+	past := time.Now().Add(-time.Hour).Unix()
+	valid := Sign("/a/b.txt", future, key)
+	tampered := []byte(valid)
+	if tampered[0] == 'A' {
+		tampered[0] = 'B'
+	} else {
+		tampered[0] = 'A'
+	}
+	// 修改过期时间后签名不再有效
+	extended := valid[:strings.LastIndex(valid, ":")+1] + "0"
+	tests := []struct {
+		name string
+		path string
+		sign string
+		key  string
+		want bool
+	}{
+		{"valid", "/a/b.txt", valid, key, true},
+		{"never expire", "/a/b.txt", Sign("/a/b.txt", 0, key), key, true},
+		{"same path not parsed", "a/b.txt/", valid, key, true},
+		{"expired", "/a/b.txt", Sign("/a/b.txt", past, key), key, false},
+		{"other path", "/a/c.txt", valid, key, false},
+		{"other key", "/a/b.txt", valid, "other", false},
+		{"tampered sign", "/a/b.txt", string(tampered), key, false},
+		{"tampered expire", "/a/b.txt", extended, key, false},
+		{"no expire", "/a/b.txt", valid[:strings.LastIndex(valid, ":")], key, false},
+		{"bad expire", "/a/b.txt", valid[:strings.LastIndex(valid, ":")] + ":x", key, false},
+		{"empty", "/a/b.txt", "", key, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifySign(tt.path, tt.sign, tt.key); got != tt.want {
+				t.Errorf("VerifySign(%q, %q) = %v, want %v", tt.path, tt.sign, got, tt.want)
+			}
+		})
+	}
+}
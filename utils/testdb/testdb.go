@@ -0,0 +1,33 @@
+// Package testdb provides the database used by the tests
+package testdb
+
+import (
+	"testing"
+
+	"github.com/Xhofe/alist/conf"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Init open a new in-memory sqlite database with the tables of the models and set it as conf.DB
+func Init(t *testing.T, models ...interface{}) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 内存数据库每个连接都是独立的，只使用一个连接
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+	if err = db.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	conf.DB = db
+}